@@ -0,0 +1,233 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Once at least this share of the arena bytes belongs to deleted keys and
+// values, resize compacts the arenas while it rebuilds the slots.
+const arenaCompactThreshold float64 = 0.5
+
+// arenaSlot refers to its key and value by arena offsets only, so a slot
+// array holds no pointers.
+type arenaSlot struct {
+	hash       uint64
+	keyOffset  uint32
+	valueIndex uint32
+	keyLength  uint8
+	state      uint8
+}
+
+// ArenaHashTable is a HashTable for values without pointers. Keys are stored
+// back to back in a byte arena, values in a value arena and the slots only
+// hold offsets into both, so none of the table's allocations is scanned by the
+// garbage collector. Deleted keys and values stay in the arenas until the
+// table is compacted.
+type ArenaHashTable[V any] struct {
+	length              uint64
+	slots               []arenaSlot
+	keys                keyArena
+	values              []V
+	deadValues          uint64
+	compactions         uint64
+	activeSlotCounter   uint64
+	occupiedSlotCounter uint64
+}
+
+// NewArena returns an ArenaHashTable, or an error if V holds pointers, which
+// the garbage collector could not see inside the value arena.
+func NewArena[V any](length uint64) (*ArenaHashTable[V], error) {
+	if t := reflect.TypeFor[V](); containsPointers(t) {
+		return nil, fmt.Errorf("value type %v contains pointers", t)
+	}
+	primeLength := pickLargestLength(length)
+	return &ArenaHashTable[V]{
+		length: primeLength,
+		slots:  make([]arenaSlot, primeLength),
+	}, nil
+}
+
+func (h *ArenaHashTable[V]) Len() int {
+	return int(h.activeSlotCounter)
+}
+
+func (h *ArenaHashTable[V]) computeLoadFactor() float32 {
+	return float32(h.occupiedSlotCounter) / float32(h.length)
+}
+
+func (h *ArenaHashTable[V]) key(slot *arenaSlot) []byte {
+	return h.keys.get(slot.keyOffset, slot.keyLength)
+}
+
+// fragmentation returns the share of the arena bytes held by deleted entries.
+func (h *ArenaHashTable[V]) fragmentation() float64 {
+	valueSize := uint64(unsafe.Sizeof(*new(V)))
+	total := uint64(len(h.keys.data)) + uint64(len(h.values))*valueSize
+	if total == 0 {
+		return 0
+	}
+	return float64(h.keys.garbage+h.deadValues*valueSize) / float64(total)
+}
+
+// place puts slot into the first free slot of its probe sequence. The key
+// must not be in the table yet.
+func (h *ArenaHashTable[V]) place(slots []arenaSlot, slot arenaSlot) {
+	var collisionCount uint64 = 0
+	location := probeIndex(slot.hash, uint64(len(slots)), collisionCount)
+	for slots[location].state != slotEmpty {
+		collisionCount++
+		location = probeIndex(slot.hash, uint64(len(slots)), collisionCount)
+	}
+	slots[location] = slot
+}
+
+func (h *ArenaHashTable[V]) resize(newSize uint64) {
+	if h.fragmentation() >= arenaCompactThreshold {
+		h.Compact()
+	}
+	newSlots := make([]arenaSlot, newSize)
+	for i := range h.slots {
+		if h.slots[i].state == slotOccupied {
+			h.place(newSlots, h.slots[i])
+		}
+	}
+	h.length = newSize
+	h.slots = newSlots
+	h.occupiedSlotCounter = h.activeSlotCounter
+}
+
+// Compact rewrites the key and value arenas with the live entries only and
+// updates the slot offsets. The slots keep their positions.
+func (h *ArenaHashTable[V]) Compact() {
+	keys := keyArena{data: make([]byte, 0, uint64(len(h.keys.data))-h.keys.garbage)}
+	values := make([]V, 0, h.activeSlotCounter)
+	for i := range h.slots {
+		slot := &h.slots[i]
+		if slot.state != slotOccupied {
+			continue
+		}
+		slot.keyOffset = keys.add(string(h.key(slot)))
+		values = append(values, h.values[slot.valueIndex])
+		slot.valueIndex = uint32(len(values) - 1)
+	}
+	h.keys = keys
+	h.values = values
+	h.deadValues = 0
+	h.compactions++
+}
+
+func (h *ArenaHashTable[V]) insertItem(index uint64, key nodeKey, value V) {
+	if uint64(len(h.values)) >= uint64(^uint32(0)) {
+		panic("The value arena can't hold more than 2^32-1 values!")
+	}
+	slot := &h.slots[index]
+	if slot.state == slotEmpty {
+		h.occupiedSlotCounter++
+	}
+	*slot = arenaSlot{
+		hash:       key.hash,
+		keyOffset:  h.keys.add(key.value),
+		valueIndex: uint32(len(h.values)),
+		keyLength:  uint8(len(key.value)),
+		state:      slotOccupied,
+	}
+	h.values = append(h.values, value)
+	h.activeSlotCounter++
+}
+
+func (h *ArenaHashTable[V]) slotState(index uint64) uint8 {
+	return h.slots[index].state
+}
+
+func (h *ArenaHashTable[V]) slotMatches(index uint64, key nodeKey) bool {
+	slot := &h.slots[index]
+	return slot.hash == key.hash && string(h.key(slot)) == key.value
+}
+
+func (h *ArenaHashTable[V]) insert(key nodeKey, value V) {
+	location, found := probeSlots(h, h.length, key)
+	switch {
+	case found:
+		// Updates overwrite the value in place and leave no garbage.
+		h.values[h.slots[location].valueIndex] = value
+	case location < h.length:
+		h.insertItem(location, key, value)
+	}
+}
+
+func (h *ArenaHashTable[V]) Insert(key string, value V) {
+	if h.computeLoadFactor() >= risizeUpThreshold {
+		h.resize(nextSizeUp(h.length))
+	}
+	h.insert(NewKey(key), value)
+}
+
+func (h *ArenaHashTable[V]) find(key string) (*arenaSlot, bool) {
+	location, found := probeSlots(h, h.length, NewKey(key))
+	if !found {
+		return nil, false
+	}
+	return &h.slots[location], true
+}
+
+func (h *ArenaHashTable[V]) Search(key string) (V, error) {
+	slot, ok := h.find(key)
+	if !ok {
+		var zero V
+		return zero, errors.New(keyNotFoundErrorMsg)
+	}
+	return h.values[slot.valueIndex], nil
+}
+
+func (h *ArenaHashTable[V]) Delete(key string) error {
+	slot, ok := h.find(key)
+	if !ok {
+		return errors.New(keyNotFoundErrorMsg)
+	}
+
+	h.keys.garbage += uint64(slot.keyLength)
+	h.deadValues++
+	slot.state = slotTombstone
+	h.activeSlotCounter--
+
+	if h.computeLoadFactor() <= resizeDownThreshold && h.length > primes[0] {
+		h.resize(nextSizeDown(h.length))
+	}
+	return nil
+}
+
+// ArenaStats describes the contents of an ArenaHashTable and how much of its
+// arenas is taken by deleted entries.
+type ArenaStats struct {
+	Live       uint64
+	Tombstones uint64
+	Capacity   uint64
+	LoadFactor float32
+	KeyBytes   uint64
+	// KeyGarbage is the number of key arena bytes held by deleted keys.
+	KeyGarbage   uint64
+	ValueBytes   uint64
+	ValueGarbage uint64
+	// Fragmentation is the share of all arena bytes held by deleted entries.
+	Fragmentation float64
+	Compactions   uint64
+}
+
+func (h *ArenaHashTable[V]) Stats() ArenaStats {
+	valueSize := uint64(unsafe.Sizeof(*new(V)))
+	return ArenaStats{
+		Live:          h.activeSlotCounter,
+		Tombstones:    h.occupiedSlotCounter - h.activeSlotCounter,
+		Capacity:      h.length,
+		LoadFactor:    h.computeLoadFactor(),
+		KeyBytes:      uint64(len(h.keys.data)),
+		KeyGarbage:    h.keys.garbage,
+		ValueBytes:    uint64(len(h.values)) * valueSize,
+		ValueGarbage:  h.deadValues * valueSize,
+		Fragmentation: h.fragmentation(),
+		Compactions:   h.compactions,
+	}
+}
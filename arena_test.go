@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestNewArenaRejectsPointerValues(t *testing.T) {
+	if _, err := NewArena[string](10); err == nil {
+		t.Errorf("NewArena[string] = nil error, expected pointer values to be rejected")
+	}
+	if _, err := NewArena[struct{ next *int }](10); err == nil {
+		t.Errorf("NewArena[struct{ next *int }] = nil error, expected pointer values to be rejected")
+	}
+	if _, err := NewArena[[4]float64](10); err != nil {
+		t.Errorf("NewArena[[4]float64] returned error: %v", err)
+	}
+	if containsPointers(reflect.TypeOf(arenaSlot{})) {
+		t.Errorf("arenaSlot contains pointers, expected a pointer-free slot")
+	}
+}
+
+func TestArenaInsertSearchDelete(t *testing.T) {
+	table, err := NewArena[int](10)
+	if err != nil {
+		t.Fatalf("NewArena returned error: %v", err)
+	}
+	key := "foo-1"
+	table.Insert(key, 100)
+	table.Insert(key, 200)
+	if value, err := table.Search(key); err != nil || value != 200 {
+		t.Errorf("Search(%s) = %d, want 200, error: %v", key, value, err)
+	}
+	if stats := table.Stats(); stats.KeyBytes != uint64(len(key)) || stats.ValueGarbage != 0 {
+		t.Errorf("Stats() = %+v, expected the update to be done in place", stats)
+	}
+	if err := table.Delete(key); err != nil {
+		t.Errorf("Delete(%s) returned error: %v", key, err)
+	}
+	if _, err := table.Search(key); err == nil {
+		t.Errorf("Search(%s) after Delete = nil error, expected key not found", key)
+	}
+	if err := table.Delete(key); err == nil {
+		t.Errorf("Delete(%s) twice = nil, expected an error", key)
+	}
+}
+
+func TestArenaCompact(t *testing.T) {
+	table, _ := NewArena[int64](1000)
+	for i := 0; i < 500; i++ {
+		table.Insert(fmt.Sprintf("foo-%d", i), int64(i))
+	}
+	for i := 0; i < 400; i++ {
+		table.Delete(fmt.Sprintf("foo-%d", i))
+	}
+
+	before := table.Stats()
+	if before.Fragmentation < 0.75 || before.ValueGarbage != 400*8 {
+		t.Errorf("Stats() = %+v, expected 400 deleted entries worth of garbage", before)
+	}
+	table.Compact()
+	after := table.Stats()
+	if after.Fragmentation != 0 || after.KeyGarbage != 0 || after.ValueBytes != 100*8 || after.Compactions != 1 {
+		t.Errorf("Stats() after Compact = %+v, expected only the 100 live entries", after)
+	}
+	for i := 400; i < 500; i++ {
+		key := fmt.Sprintf("foo-%d", i)
+		if value, err := table.Search(key); err != nil || value != int64(i) {
+			t.Errorf("Search(%s) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+}
+
+func TestArenaResizeCompactsFragmentedArenas(t *testing.T) {
+	table, _ := NewArena[int](10)
+	for i := 0; i < 10; i++ {
+		table.Insert(fmt.Sprintf("foo-%d", i), i)
+	}
+	for i := 0; i < 8; i++ {
+		table.Delete(fmt.Sprintf("foo-%d", i))
+	}
+	table.resize(table.length)
+	if stats := table.Stats(); stats.Compactions != 1 || stats.Fragmentation != 0 {
+		t.Errorf("Stats() = %+v, expected resize to compact the arenas", stats)
+	}
+}
+
+func BenchmarkGCWithArenaHashTable(b *testing.B) {
+	benchmarkGC(b, func(keys []string) any {
+		table, _ := NewArena[int](uint64(len(keys)))
+		for i, key := range keys {
+			table.Insert(key, i)
+		}
+		return table
+	})
+}
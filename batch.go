@@ -0,0 +1,98 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+)
+
+// batchEntry is a key of a batch together with its home slot and its position
+// in the batch.
+type batchEntry struct {
+	key   nodeKey
+	home  uint64
+	index int
+}
+
+// Batches with at least one key per this many slots are ordered with a
+// counting sort over the slots; smaller ones are sorted by comparison, so a
+// few keys don't allocate an array the size of the table.
+const countingSortSlotsPerKey = 8
+
+// prepareBatch hashes all keys up front and orders them by home slot, so the
+// probes of a batch walk the slot array from front to back. Keys with the
+// same home slot keep their batch order.
+func (h *HashTable[V]) prepareBatch(keys []string) []batchEntry {
+	entries := make([]batchEntry, len(keys))
+	for i, key := range keys {
+		k := NewKey(key)
+		entries[i] = batchEntry{key: k, home: h.doubleHashing(k, 0), index: i}
+	}
+	if uint64(len(keys))*countingSortSlotsPerKey < h.length {
+		slices.SortStableFunc(entries, func(a, b batchEntry) int { return cmp.Compare(a.home, b.home) })
+		return entries
+	}
+
+	// Counting sort by home slot, which is stable and linear in the batch
+	// size plus the table length.
+	starts := make([]uint32, h.length+1)
+	for _, entry := range entries {
+		starts[entry.home+1]++
+	}
+	for i := 1; i < len(starts); i++ {
+		starts[i] += starts[i-1]
+	}
+	sorted := make([]batchEntry, len(entries))
+	for _, entry := range entries {
+		sorted[starts[entry.home]] = entry
+		starts[entry.home]++
+	}
+	return sorted
+}
+
+// InsertMany inserts keys[i] with values[i] for every i. The table is resized
+// at most once, up front, to hold all keys. When a key occurs more than once,
+// its last value wins like with consecutive Insert calls.
+func (h *HashTable[V]) InsertMany(keys []string, values []V) {
+	if len(keys) != len(values) {
+		panic("InsertMany needs exactly one value per key!")
+	}
+
+	// Sized for the case that every key is new.
+	needed := lengthFor(int(h.occupiedSlotCounter) + len(keys))
+	if needed > h.length {
+		h.resize(getPrime(needed, true))
+	}
+
+	for _, entry := range h.prepareBatch(keys) {
+		collisionCount := h.insert(h.slots, entry.key, values[entry.index])
+		h.stats.inserts.record(collisionCount)
+	}
+}
+
+// SearchMany looks up every key and returns the values and errors in the
+// order of keys. errs[i] is nil when keys[i] was found.
+func (h *HashTable[V]) SearchMany(keys []string) (values []V, errs []error) {
+	values = make([]V, len(keys))
+	errs = make([]error, len(keys))
+	for _, entry := range h.prepareBatch(keys) {
+		values[entry.index], errs[entry.index] = h.search(entry.key)
+	}
+	return values, errs
+}
+
+// DeleteMany deletes every key and returns the errors in the order of keys.
+// errs[i] is nil when keys[i] was deleted. The table is shrunk after the whole
+// batch, rather than after each key.
+func (h *HashTable[V]) DeleteMany(keys []string) (errs []error) {
+	errs = make([]error, len(keys))
+	for _, entry := range h.prepareBatch(keys) {
+		errs[entry.index] = h.delete(entry.key)
+	}
+	for {
+		length := h.length
+		h.shrink()
+		if h.length == length {
+			return errs
+		}
+	}
+}
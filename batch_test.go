@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestInsertManyResizesOnce(t *testing.T) {
+	table := New[int](10)
+	keys := makeSequentialKeys(10_000)
+	values := make([]int, len(keys))
+	for i := range values {
+		values[i] = i
+	}
+
+	table.InsertMany(keys, values)
+	if stats := table.Stats(); stats.Grows != 1 {
+		t.Errorf("InsertMany grew the table %d times, expected: 1", stats.Grows)
+	}
+	if table.Len() != len(keys) {
+		t.Errorf("Len() = %d, expected: %d", table.Len(), len(keys))
+	}
+	if loadFactor := table.computeLoadFactor(); loadFactor >= risizeUpThreshold {
+		t.Errorf("load factor after InsertMany = %f, expected less than %f", loadFactor, risizeUpThreshold)
+	}
+	for i, key := range keys {
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Fatalf("Search(%s) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+}
+
+func TestInsertManyLastValueWins(t *testing.T) {
+	table := New[int](10)
+	table.Insert("foo-1", 0)
+	table.InsertMany([]string{"foo-1", "foo-2", "foo-1"}, []int{1, 2, 3})
+
+	if value, _ := table.Search("foo-1"); value != 3 {
+		t.Errorf("Search(foo-1) = %d, expected the last value of the batch: 3", value)
+	}
+	if table.Len() != 2 {
+		t.Errorf("Len() = %d, expected: 2", table.Len())
+	}
+}
+
+func TestInsertManyPanicsOnLengthMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("InsertMany with more keys than values did not panic")
+		}
+	}()
+	New[int](10).InsertMany([]string{"foo-1", "foo-2"}, []int{1})
+}
+
+func TestSearchManyAndDeleteMany(t *testing.T) {
+	table := New[int](10)
+	for i := 0; i < 100; i++ {
+		table.Insert(fmt.Sprintf("foo-%d", i), i)
+	}
+
+	keys := []string{"foo-42", "missing", "foo-7", "foo-42"}
+	values, errs := table.SearchMany(keys)
+	expected := []int{42, 0, 7, 42}
+	for i, key := range keys {
+		found := key != "missing"
+		if values[i] != expected[i] || (errs[i] == nil) != found {
+			t.Errorf("SearchMany()[%d] (%s) = %d, %v, expected: %d, found: %v", i, key, values[i], errs[i], expected[i], found)
+		}
+	}
+
+	errs = table.DeleteMany(keys)
+	for i, expectErr := range []bool{false, true, false, true} {
+		if (errs[i] != nil) != expectErr {
+			t.Errorf("DeleteMany()[%d] (%s) = %v, expected an error: %v", i, keys[i], errs[i], expectErr)
+		}
+	}
+	if table.Len() != 98 {
+		t.Errorf("Len() = %d, expected: 98", table.Len())
+	}
+}
+
+func TestDeleteManyShrinksAfterBatch(t *testing.T) {
+	table := New[int](10)
+	keys := makeSequentialKeys(10_000)
+	for i, key := range keys {
+		table.Insert(key, i)
+	}
+	table.ResetStats()
+
+	table.DeleteMany(keys[:9_990])
+	if table.Len() != 10 {
+		t.Errorf("Len() = %d, expected: 10", table.Len())
+	}
+	if table.computeLoadFactor() <= resizeDownThreshold && table.length > primes[0] {
+		t.Errorf("table of length %d with load factor %f was not shrunk", table.length, table.computeLoadFactor())
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after DeleteMany: %v", err)
+	}
+}
+
+func BenchmarkInsertMany(b *testing.B) {
+	totalKeys := 1_000_000
+	keys := makeSequentialKeys(totalKeys)
+	values := make([]int, totalKeys)
+	for i := range values {
+		values[i] = i
+	}
+
+	// Starts as small as BenchmarkInsertWithResize.
+	var tableLength uint64 = 100_000
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		table := New[int](tableLength)
+		b.StartTimer()
+		table.InsertMany(keys, values)
+	}
+}
+
+func TestPrepareBatchSortsBothWays(t *testing.T) {
+	table := New[int](100_000)
+	for _, size := range []int{3, int(table.length)} {
+		keys := makeSequentialKeys(size)
+		// Repeat a key so equal home slots have to keep their batch order.
+		keys[size-1] = keys[0]
+		entries := table.prepareBatch(keys)
+		for i := 1; i < len(entries); i++ {
+			previous, entry := entries[i-1], entries[i]
+			if previous.home > entry.home || previous.home == entry.home && previous.index > entry.index {
+				t.Fatalf("batch of %d: entries %d and %d are out of order: %+v, %+v", size, i-1, i, previous, entry)
+			}
+		}
+	}
+	// A small batch must not allocate per slot of the table.
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	table.SearchMany([]string{"a", "b", "c"})
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 4096 {
+		t.Errorf("SearchMany of 3 keys on %d slots allocated %d bytes", table.length, allocated)
+	}
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Operations of a workload mix.
+const (
+	benchRead = iota
+	benchInsert
+	benchUpdate
+	benchDelete
+	// benchMiss reads a key that was never inserted.
+	benchMiss
+	benchOpCount
+)
+
+var benchOpNames = [benchOpCount]string{"read", "insert", "update", "delete", "miss"}
+
+// benchWorkload is a YCSB core workload preset.
+type benchWorkload struct {
+	mix string
+	// distribution is used unless -distribution is given.
+	distribution string
+}
+
+// The YCSB core workloads that only need point operations. Workload D reads
+// the latest records, the others pick records by popularity.
+var benchWorkloads = map[string]benchWorkload{
+	"a": {mix: "read=50,update=50", distribution: "zipfian"},
+	"b": {mix: "read=95,update=5", distribution: "zipfian"},
+	"c": {mix: "read=100", distribution: "zipfian"},
+	"d": {mix: "read=95,insert=5", distribution: "latest"},
+}
+
+// benchMix holds the relative weights of every operation.
+type benchMix [benchOpCount]int
+
+func parseBenchMix(mix string) (benchMix, error) {
+	var parsed benchMix
+	total := 0
+	for _, part := range strings.Split(mix, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return parsed, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		op := -1
+		for i, opName := range benchOpNames {
+			if opName == name {
+				op = i
+			}
+		}
+		if op < 0 {
+			return parsed, fmt.Errorf("unknown operation %q in mix", name)
+		}
+		value, err := strconv.Atoi(weight)
+		if err != nil || value < 0 {
+			return parsed, fmt.Errorf("invalid weight %q for operation %s", weight, name)
+		}
+		parsed[op] = value
+		total += value
+	}
+	if total == 0 {
+		return parsed, errors.New("mix weights add up to zero")
+	}
+	return parsed, nil
+}
+
+func (m benchMix) String() string {
+	var parts []string
+	for op, weight := range m {
+		if weight > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", benchOpNames[op], weight))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// pick maps a random number to an operation according to the weights.
+func (m benchMix) pick(r *rand.Rand) int {
+	total := 0
+	for _, weight := range m {
+		total += weight
+	}
+	n := r.Intn(total)
+	for op, weight := range m {
+		if n < weight {
+			return op
+		}
+		n -= weight
+	}
+	return benchRead
+}
+
+// benchKeyLength describes the distribution of key lengths. Keys of the same
+// record always have the same length, so reads find what was inserted.
+type benchKeyLength struct {
+	min int
+	max int
+}
+
+func parseBenchKeyLength(spec string) (benchKeyLength, error) {
+	kind, bounds, _ := strings.Cut(spec, ":")
+	var length benchKeyLength
+	var err error
+	switch kind {
+	case "fixed":
+		length.min, err = strconv.Atoi(bounds)
+		length.max = length.min
+	case "uniform":
+		low, high, ok := strings.Cut(bounds, "-")
+		if !ok {
+			return length, fmt.Errorf("invalid key length %q, expected uniform:min-max", spec)
+		}
+		if length.min, err = strconv.Atoi(low); err == nil {
+			length.max, err = strconv.Atoi(high)
+		}
+	default:
+		return length, fmt.Errorf("unknown key length distribution %q", kind)
+	}
+	if err != nil {
+		return length, fmt.Errorf("invalid key length %q: %w", spec, err)
+	}
+	if length.min < 1 || length.max < length.min || length.max > maxKeyLength {
+		return length, fmt.Errorf("key lengths must be between 1 and %d, got %q", maxKeyLength, spec)
+	}
+	return length, nil
+}
+
+// key returns the key of record i: the base 36 record number, zero padded
+// after the sign to the length of the record. Records whose number does not
+// fit are rejected up front by fits.
+func (l benchKeyLength) key(i int) string {
+	digits := strconv.FormatInt(int64(i), 36)
+	length := l.min
+	if l.max > l.min {
+		length += int(fnvHash(digits) % uint64(l.max-l.min+1))
+	}
+	if len(digits) >= length {
+		return digits
+	}
+	padding := strings.Repeat("0", length-len(digits))
+	if i < 0 {
+		return "-" + padding + digits[1:]
+	}
+	return padding + digits
+}
+
+// fits returns an error if the shortest keys cannot hold the record numbers a
+// workload may use: up to records+operations for inserts, and as low as
+// -(records+operations) for misses.
+func (l benchKeyLength) fits(records, operations int) error {
+	needed := len(strconv.FormatInt(-int64(records+operations), 36))
+	if l.min < needed {
+		return fmt.Errorf("keys of %d characters are too short for %d records and %d operations, use at least %d", l.min, records, operations, needed)
+	}
+	return nil
+}
+
+// benchChooser picks the record an operation is applied to.
+type benchChooser struct {
+	distribution string
+	r            *rand.Rand
+	zipf         *rand.Zipf
+}
+
+func newBenchChooser(distribution string, r *rand.Rand, records int) (*benchChooser, error) {
+	chooser := &benchChooser{distribution: distribution, r: r}
+	switch distribution {
+	case "uniform":
+	case "zipfian", "latest":
+		// math/rand requires s > 1, YCSB uses a constant just below 1.
+		chooser.zipf = rand.NewZipf(r, 1.01, 1, uint64(records-1))
+	default:
+		return nil, fmt.Errorf("unknown key distribution %q", distribution)
+	}
+	return chooser, nil
+}
+
+func (c *benchChooser) next(records int) int {
+	switch c.distribution {
+	case "zipfian":
+		return int(c.zipf.Uint64()) % records
+	case "latest":
+		return records - 1 - int(c.zipf.Uint64())%records
+	}
+	return c.r.Intn(records)
+}
+
+// benchTarget is a key-value store the workload is run against.
+type benchTarget interface {
+	insert(key string, value int)
+	search(key string) bool
+	delete(key string) bool
+}
+
+type hashTableTarget struct {
+	table *HashTable[int]
+}
+
+func (t hashTableTarget) insert(key string, value int) { t.table.Insert(key, value) }
+
+func (t hashTableTarget) search(key string) bool {
+	_, err := t.table.Search(key)
+	return err == nil
+}
+
+func (t hashTableTarget) delete(key string) bool { return t.table.Delete(key) == nil }
+
+type columnarTarget struct {
+	table *ColumnarHashTable[int]
+}
+
+func (t columnarTarget) insert(key string, value int) { t.table.Insert(key, value) }
+
+func (t columnarTarget) search(key string) bool {
+	_, err := t.table.Search(key)
+	return err == nil
+}
+
+func (t columnarTarget) delete(key string) bool { return t.table.Delete(key) == nil }
+
+type inlineKeyTarget struct {
+	table *InlineKeyHashTable[int]
+}
+
+func (t inlineKeyTarget) insert(key string, value int) { t.table.Insert(key, value) }
+
+func (t inlineKeyTarget) search(key string) bool {
+	_, err := t.table.Search(key)
+	return err == nil
+}
+
+func (t inlineKeyTarget) delete(key string) bool { return t.table.Delete(key) == nil }
+
+type arenaTarget struct {
+	table *ArenaHashTable[int]
+}
+
+func (t arenaTarget) insert(key string, value int) { t.table.Insert(key, value) }
+
+func (t arenaTarget) search(key string) bool {
+	_, err := t.table.Search(key)
+	return err == nil
+}
+
+func (t arenaTarget) delete(key string) bool { return t.table.Delete(key) == nil }
+
+type mapTarget map[string]int
+
+func (m mapTarget) insert(key string, value int) { m[key] = value }
+
+func (m mapTarget) search(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+func (m mapTarget) delete(key string) bool {
+	_, ok := m[key]
+	delete(m, key)
+	return ok
+}
+
+var benchTargets = map[string]func(records int) benchTarget{
+	"hashtable": func(records int) benchTarget { return hashTableTarget{New[int](uint64(records))} },
+	"columnar":  func(records int) benchTarget { return columnarTarget{NewColumnar[int](uint64(records))} },
+	"inline":    func(records int) benchTarget { return inlineKeyTarget{NewInlineKey[int](uint64(records))} },
+	"filtered": func(records int) benchTarget {
+		table := New[int](uint64(records))
+		table.EnableFilter(0)
+		return hashTableTarget{table}
+	},
+	"arena": func(records int) benchTarget {
+		table, _ := NewArena[int](uint64(records))
+		return arenaTarget{table}
+	},
+	"map": func(records int) benchTarget { return mapTarget(make(map[string]int, records)) },
+}
+
+type benchStep struct {
+	op  int
+	key string
+}
+
+type benchConfig struct {
+	mix          benchMix
+	distribution string
+	keyLength    benchKeyLength
+	records      int
+	operations   int
+	// Every sampleEvery-th operation is timed individually.
+	sampleEvery int
+	seed        int64
+}
+
+type BenchResult struct {
+	Target       string  `json:"target"`
+	Records      int     `json:"records"`
+	Operations   int     `json:"operations"`
+	Mix          string  `json:"mix"`
+	Distribution string  `json:"distribution"`
+	DurationNs   int64   `json:"duration_ns"`
+	OpsPerSecond float64 `json:"ops_per_second"`
+	P50Ns        int64   `json:"p50_ns"`
+	P90Ns        int64   `json:"p90_ns"`
+	P99Ns        int64   `json:"p99_ns"`
+	P999Ns       int64   `json:"p999_ns"`
+	MaxNs        int64   `json:"max_ns"`
+	AllocsPerOp  float64 `json:"allocs_per_op"`
+	BytesPerOp   float64 `json:"bytes_per_op"`
+	Hits         int     `json:"hits"`
+	Misses       int     `json:"misses"`
+}
+
+func percentile(sorted []time.Duration, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	index := int(p * float64(len(sorted)-1))
+	return int64(sorted[index])
+}
+
+// runBenchWorkload loads config.records records into the target and then runs
+// config.operations operations of the mix against it. Only the second phase is
+// measured. The same seed yields the same operation sequence for every target.
+func runBenchWorkload(name string, target benchTarget, config benchConfig) (BenchResult, error) {
+	if err := config.keyLength.fits(config.records, config.operations); err != nil {
+		return BenchResult{}, err
+	}
+	r := rand.New(rand.NewSource(config.seed))
+	chooser, err := newBenchChooser(config.distribution, r, config.records)
+	if err != nil {
+		return BenchResult{}, err
+	}
+
+	for i := 0; i < config.records; i++ {
+		target.insert(config.keyLength.key(i), i)
+	}
+	records := config.records
+
+	// The operations and their keys are generated up front, so that key
+	// formatting is neither timed nor counted as allocations of the target.
+	steps := make([]benchStep, config.operations)
+	for i := range steps {
+		op := config.mix.pick(r)
+		var key string
+		switch op {
+		case benchInsert:
+			key = config.keyLength.key(records)
+			records++
+		case benchMiss:
+			// Negative record numbers are never inserted.
+			key = config.keyLength.key(-1 - chooser.next(records))
+		default:
+			key = config.keyLength.key(chooser.next(records))
+		}
+		steps[i] = benchStep{op: op, key: key}
+	}
+
+	samples := make([]time.Duration, 0, config.operations/config.sampleEvery+1)
+	hits, misses := 0, 0
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	for i, step := range steps {
+		sampled := i%config.sampleEvery == 0
+		var opStart time.Time
+		if sampled {
+			opStart = time.Now()
+		}
+		found := true
+		switch step.op {
+		case benchRead, benchMiss:
+			found = target.search(step.key)
+		case benchInsert, benchUpdate:
+			target.insert(step.key, i)
+		case benchDelete:
+			found = target.delete(step.key)
+		}
+		if sampled {
+			samples = append(samples, time.Since(opStart))
+		}
+		if found {
+			hits++
+		} else {
+			misses++
+		}
+	}
+
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	result := BenchResult{
+		Target:       name,
+		Records:      config.records,
+		Operations:   config.operations,
+		Mix:          config.mix.String(),
+		Distribution: config.distribution,
+		DurationNs:   elapsed.Nanoseconds(),
+		OpsPerSecond: float64(config.operations) / elapsed.Seconds(),
+		P50Ns:        percentile(samples, 0.50),
+		P90Ns:        percentile(samples, 0.90),
+		P99Ns:        percentile(samples, 0.99),
+		P999Ns:       percentile(samples, 0.999),
+		AllocsPerOp:  float64(after.Mallocs-before.Mallocs) / float64(config.operations),
+		BytesPerOp:   float64(after.TotalAlloc-before.TotalAlloc) / float64(config.operations),
+		Hits:         hits,
+		Misses:       misses,
+	}
+	if len(samples) > 0 {
+		result.MaxNs = int64(samples[len(samples)-1])
+	}
+	return result, nil
+}
+
+func writeBenchCSV(w io.Writer, results []BenchResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"target", "records", "operations", "mix", "distribution", "duration_ns", "ops_per_second",
+		"p50_ns", "p90_ns", "p99_ns", "p999_ns", "max_ns", "allocs_per_op", "bytes_per_op", "hits", "misses",
+	})
+	for _, result := range results {
+		cw.Write([]string{
+			result.Target,
+			strconv.Itoa(result.Records),
+			strconv.Itoa(result.Operations),
+			result.Mix,
+			result.Distribution,
+			strconv.FormatInt(result.DurationNs, 10),
+			strconv.FormatFloat(result.OpsPerSecond, 'f', 0, 64),
+			strconv.FormatInt(result.P50Ns, 10),
+			strconv.FormatInt(result.P90Ns, 10),
+			strconv.FormatInt(result.P99Ns, 10),
+			strconv.FormatInt(result.P999Ns, 10),
+			strconv.FormatInt(result.MaxNs, 10),
+			strconv.FormatFloat(result.AllocsPerOp, 'f', 2, 64),
+			strconv.FormatFloat(result.BytesPerOp, 'f', 2, 64),
+			strconv.Itoa(result.Hits),
+			strconv.Itoa(result.Misses),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeBenchJSON(w io.Writer, results []BenchResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// runBench implements the bench command: it runs a workload against every
+// target for every table size of the sweep and reports the results.
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	workload := flags.String("workload", "a", "YCSB core workload preset: a, b, c or d")
+	mixSpec := flags.String("mix", "", "operation weights, e.g. read=80,insert=10,update=5,delete=5,miss=0; overrides -workload")
+	distribution := flags.String("distribution", "", "key distribution: uniform, zipfian or latest; defaults to the one of -workload, zipfian for -mix")
+	keyLengthSpec := flags.String("keylen", "fixed:16", "key length distribution: fixed:n or uniform:min-max")
+	sizes := flags.String("sizes", "100000,1000000", "comma separated number of records loaded before each run")
+	operations := flags.Int("ops", 1_000_000, "operations per run")
+	targets := flags.String("targets", "hashtable,map", "comma separated targets: hashtable, columnar, inline, arena, filtered, map")
+	sampleEvery := flags.Int("sample", 16, "time every n-th operation for latency percentiles")
+	format := flags.String("format", "csv", "output format: csv or json")
+	outPath := flags.String("out", "", "output file, defaults to stdout")
+	seed := flags.Int64("seed", 1, "random seed")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	defaultDistribution := "zipfian"
+	if *mixSpec == "" {
+		preset, ok := benchWorkloads[*workload]
+		if !ok {
+			return fmt.Errorf("unknown workload %q", *workload)
+		}
+		*mixSpec = preset.mix
+		defaultDistribution = preset.distribution
+	}
+	if *distribution == "" {
+		*distribution = defaultDistribution
+	}
+	mix, err := parseBenchMix(*mixSpec)
+	if err != nil {
+		return err
+	}
+	keyLength, err := parseBenchKeyLength(*keyLengthSpec)
+	if err != nil {
+		return err
+	}
+	if *operations < 1 || *sampleEvery < 1 {
+		return errors.New("bench: -ops and -sample must be positive")
+	}
+	var write func(io.Writer, []BenchResult) error
+	switch *format {
+	case "csv":
+		write = writeBenchCSV
+	case "json":
+		write = writeBenchJSON
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+
+	var results []BenchResult
+	for _, size := range strings.Split(*sizes, ",") {
+		records, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil || records < 2 {
+			return fmt.Errorf("invalid table size %q", size)
+		}
+		config := benchConfig{
+			mix:          mix,
+			distribution: *distribution,
+			keyLength:    keyLength,
+			records:      records,
+			operations:   *operations,
+			sampleEvery:  *sampleEvery,
+			seed:         *seed,
+		}
+		for _, name := range strings.Split(*targets, ",") {
+			newTarget, ok := benchTargets[name]
+			if !ok {
+				return fmt.Errorf("unknown target %q", name)
+			}
+			result, err := runBenchWorkload(name, newTarget(records), config)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+	}
+
+	if *outPath == "" {
+		return write(os.Stdout, results)
+	}
+	out, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := write(out, results); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
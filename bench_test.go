@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseBenchMix(t *testing.T) {
+	mix, err := parseBenchMix("read=80, insert=10,update=5,delete=5")
+	if err != nil {
+		t.Fatalf("parseBenchMix returned error: %v", err)
+	}
+	expected := benchMix{80, 10, 5, 5}
+	if mix != expected {
+		t.Errorf("parseBenchMix = %v, expected: %v", mix, expected)
+	}
+	if mix.String() != "read=80,insert=10,update=5,delete=5" {
+		t.Errorf("benchMix.String() = %s", mix.String())
+	}
+
+	for _, invalid := range []string{"read", "scan=10", "read=-1", "read=0"} {
+		if _, err := parseBenchMix(invalid); err == nil {
+			t.Errorf("parseBenchMix(%s) = nil, expected an error", invalid)
+		}
+	}
+}
+
+func TestBenchMixPickFollowsWeights(t *testing.T) {
+	mix := benchMix{0, 0, 1, 0}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if op := mix.pick(r); op != benchUpdate {
+			t.Fatalf("pick = %s, expected only updates", benchOpNames[op])
+		}
+	}
+}
+
+func TestBenchKeyLength(t *testing.T) {
+	length, err := parseBenchKeyLength("uniform:10-36")
+	if err != nil {
+		t.Fatalf("parseBenchKeyLength returned error: %v", err)
+	}
+	seen := make(map[string]bool)
+	for i := 0; i < 10_000; i++ {
+		key := length.key(i)
+		if len(key) < 10 || len(key) > 36 {
+			t.Fatalf("key(%d) = %s has length %d, expected between 10 and 36", i, key, len(key))
+		}
+		if seen[key] {
+			t.Fatalf("key(%d) = %s is not unique", i, key)
+		}
+		seen[key] = true
+	}
+	if length.key(42) != length.key(42) {
+		t.Errorf("key(42) is not deterministic")
+	}
+
+	short := benchKeyLength{min: 3, max: 3}
+	for _, i := range []int{0, 35, 46655, -1, -1295} {
+		if key := short.key(i); len(key) != 3 {
+			t.Errorf("key(%d) = %s, expected 3 characters", i, key)
+		}
+	}
+	if short.key(5) == short.key(-5) {
+		t.Errorf("key(5) = key(-5) = %s, expected misses to differ", short.key(5))
+	}
+	if err := short.fits(1000, 295); err != nil {
+		t.Errorf("fits(1000, 295) = %v, expected 3 characters to be enough", err)
+	}
+	if err := short.fits(1000, 296); err == nil {
+		t.Errorf("fits(1000, 296) = nil, expected -1296 not to fit in 3 characters")
+	}
+
+	for _, invalid := range []string{"fixed:0", "fixed:37", "uniform:10", "uniform:20-10", "normal:10"} {
+		if _, err := parseBenchKeyLength(invalid); err == nil {
+			t.Errorf("parseBenchKeyLength(%s) = nil, expected an error", invalid)
+		}
+	}
+}
+
+func TestBenchChooserStaysInRange(t *testing.T) {
+	for _, distribution := range []string{"uniform", "zipfian", "latest"} {
+		chooser, err := newBenchChooser(distribution, rand.New(rand.NewSource(1)), 100)
+		if err != nil {
+			t.Fatalf("newBenchChooser(%s) returned error: %v", distribution, err)
+		}
+		for i := 0; i < 1000; i++ {
+			if record := chooser.next(100 + i); record < 0 || record >= 100+i {
+				t.Fatalf("%s chooser picked record %d out of %d", distribution, record, 100+i)
+			}
+		}
+	}
+	if _, err := newBenchChooser("gaussian", rand.New(rand.NewSource(1)), 100); err == nil {
+		t.Errorf("newBenchChooser(gaussian) = nil, expected an error")
+	}
+}
+
+func TestRunBenchWorkloadTargetsAgree(t *testing.T) {
+	config := benchConfig{
+		mix:          benchMix{50, 20, 20, 10},
+		distribution: "zipfian",
+		keyLength:    benchKeyLength{min: 8, max: 20},
+		records:      1000,
+		operations:   5000,
+		sampleEvery:  4,
+		seed:         7,
+	}
+	var results []BenchResult
+	for _, name := range []string{"hashtable", "map"} {
+		result, err := runBenchWorkload(name, benchTargets[name](config.records), config)
+		if err != nil {
+			t.Fatalf("runBenchWorkload(%s) returned error: %v", name, err)
+		}
+		if result.Hits+result.Misses != config.operations {
+			t.Errorf("%s: hits + misses = %d, expected: %d", name, result.Hits+result.Misses, config.operations)
+		}
+		if result.P50Ns > result.P99Ns || result.P99Ns > result.MaxNs {
+			t.Errorf("%s: percentiles are not ordered: %+v", name, result)
+		}
+		results = append(results, result)
+	}
+	if results[0].Hits != results[1].Hits {
+		t.Errorf("hashtable hits = %d, map hits = %d, expected the same workload to give the same hits", results[0].Hits, results[1].Hits)
+	}
+}
+
+func TestRunBenchRejectsShortKeys(t *testing.T) {
+	args := []string{"-sizes", "100000", "-ops", "100", "-keylen", "fixed:3", "-out", filepath.Join(t.TempDir(), "results.csv")}
+	if err := runBench(args); err == nil {
+		t.Errorf("runBench(%v) = nil, expected keys of 3 characters to be rejected", args)
+	}
+}
+
+func TestRunBenchOutputFormats(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "results.csv")
+	jsonPath := filepath.Join(dir, "results.json")
+	common := []string{"-sizes", "100,200", "-ops", "500", "-workload", "b"}
+
+	if err := runBench(append(common, "-format", "csv", "-out", csvPath)); err != nil {
+		t.Fatalf("runBench csv returned error: %v", err)
+	}
+	file, _ := os.Open(csvPath)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV output: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Errorf("CSV output has %d rows, expected a header and 2 sizes x 2 targets", len(rows))
+	}
+
+	if err := runBench(append(common, "-format", "json", "-out", jsonPath, "-distribution", "latest")); err != nil {
+		t.Fatalf("runBench json returned error: %v", err)
+	}
+	encoded, _ := os.ReadFile(jsonPath)
+	var results []BenchResult
+	if err := json.Unmarshal(encoded, &results); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(results) != 4 || results[0].Mix != "read=95,update=5" {
+		t.Errorf("JSON results = %+v, expected 4 results of workload b", results)
+	}
+
+	if err := runBench([]string{"-targets", "btree"}); err == nil {
+		t.Errorf("runBench with an unknown target = nil, expected an error")
+	}
+}
+
+func TestRunBenchWorkloadDistribution(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-workload", "d"}, "latest"},
+		{[]string{"-workload", "d", "-distribution", "uniform"}, "uniform"},
+		{[]string{"-workload", "a"}, "zipfian"},
+		{[]string{"-mix", "read=100"}, "zipfian"},
+	}
+	for _, tc := range tests {
+		out := filepath.Join(t.TempDir(), "results.json")
+		args := append([]string{"-sizes", "100", "-ops", "100", "-targets", "map", "-format", "json", "-out", out}, tc.args...)
+		if err := runBench(args); err != nil {
+			t.Fatalf("runBench(%v) returned error: %v", tc.args, err)
+		}
+		encoded, _ := os.ReadFile(out)
+		var results []BenchResult
+		if err := json.Unmarshal(encoded, &results); err != nil || len(results) != 1 {
+			t.Fatalf("runBench(%v) wrote %s, expected one JSON result", tc.args, encoded)
+		}
+		if results[0].Distribution != tc.expected {
+			t.Errorf("runBench(%v) used distribution %s, expected: %s", tc.args, results[0].Distribution, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConflictPolicy decides what BiHashTable.Insert does when the key or the
+// value is already paired with something else.
+type ConflictPolicy int
+
+const (
+	// ConflictReplace deletes the pairs holding the key or the value before
+	// inserting the new pair.
+	ConflictReplace ConflictPolicy = iota
+	// ConflictReject leaves the table unchanged and returns an error
+	// wrapping ErrConflict.
+	ConflictReject
+)
+
+// ErrConflict is returned by BiHashTable.Insert under ConflictReject.
+var ErrConflict = errors.New("conflicting pair")
+
+// BiHashTable is a one-to-one mapping between keys and values, kept as two
+// HashTables indexing the pairs by key and by value. Values are limited to
+// maxKeyLength characters like keys.
+type BiHashTable struct {
+	forward  *HashTable[string]
+	backward *HashTable[string]
+	policy   ConflictPolicy
+}
+
+func NewBi(length uint64, policy ConflictPolicy) *BiHashTable {
+	return &BiHashTable{
+		forward:  New[string](length),
+		backward: New[string](length),
+		policy:   policy,
+	}
+}
+
+// Len returns the number of pairs.
+func (b *BiHashTable) Len() int {
+	return b.forward.Len()
+}
+
+// Insert pairs key with value. Inserting a pair that is already present does
+// nothing; any other pair sharing the key or the value is a conflict resolved
+// by the table's ConflictPolicy.
+func (b *BiHashTable) Insert(key, value string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if err := validateKey(value); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+	currentValue, keyErr := b.forward.Search(key)
+	currentKey, valueErr := b.backward.Search(value)
+	if keyErr == nil && currentValue == value {
+		return nil
+	}
+
+	if b.policy == ConflictReject {
+		if keyErr == nil {
+			return fmt.Errorf("%w: key %q is paired with value %q", ErrConflict, key, currentValue)
+		}
+		if valueErr == nil {
+			return fmt.Errorf("%w: value %q is paired with key %q", ErrConflict, value, currentKey)
+		}
+	}
+	if keyErr == nil {
+		b.backward.Delete(currentValue)
+	}
+	if valueErr == nil {
+		b.forward.Delete(currentKey)
+	}
+	b.forward.Insert(key, value)
+	b.backward.Insert(value, key)
+	return nil
+}
+
+func (b *BiHashTable) GetByKey(key string) (string, error) {
+	return b.forward.Search(key)
+}
+
+func (b *BiHashTable) GetByValue(value string) (string, error) {
+	return b.backward.Search(value)
+}
+
+// DeleteByKey deletes the pair holding key.
+func (b *BiHashTable) DeleteByKey(key string) error {
+	value, err := b.forward.Search(key)
+	if err != nil {
+		return err
+	}
+	b.forward.Delete(key)
+	b.backward.Delete(value)
+	return nil
+}
+
+// DeleteByValue deletes the pair holding value.
+func (b *BiHashTable) DeleteByValue(value string) error {
+	key, err := b.backward.Search(value)
+	if err != nil {
+		return err
+	}
+	b.forward.Delete(key)
+	b.backward.Delete(value)
+	return nil
+}
+
+// Validate checks both indexes and that they hold the same pairs.
+func (b *BiHashTable) Validate() error {
+	if err := b.forward.Validate(); err != nil {
+		return fmt.Errorf("key index: %w", err)
+	}
+	if err := b.backward.Validate(); err != nil {
+		return fmt.Errorf("value index: %w", err)
+	}
+	if b.forward.Len() != b.backward.Len() {
+		return fmt.Errorf("key index has %d pairs, value index has %d", b.forward.Len(), b.backward.Len())
+	}
+	for key, value := range b.forward.All() {
+		if got, err := b.backward.Search(value); err != nil || got != key {
+			return fmt.Errorf("pair %q -> %q missing from the value index", key, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBiGetAndDelete(t *testing.T) {
+	bi := NewBi(10, ConflictReject)
+	for i := 0; i < 500; i++ {
+		if err := bi.Insert(fmt.Sprintf("id-%d", i), fmt.Sprintf("name-%d", i)); err != nil {
+			t.Fatalf("Insert(id-%d): %v", i, err)
+		}
+	}
+	if value, err := bi.GetByKey("id-42"); err != nil || value != "name-42" {
+		t.Errorf("GetByKey(id-42) = %q, %v, expected: name-42", value, err)
+	}
+	if key, err := bi.GetByValue("name-42"); err != nil || key != "id-42" {
+		t.Errorf("GetByValue(name-42) = %q, %v, expected: id-42", key, err)
+	}
+
+	if err := bi.DeleteByKey("id-1"); err != nil {
+		t.Errorf("DeleteByKey(id-1): %v", err)
+	}
+	if err := bi.DeleteByValue("name-2"); err != nil {
+		t.Errorf("DeleteByValue(name-2): %v", err)
+	}
+	if _, err := bi.GetByValue("name-1"); err == nil {
+		t.Errorf("GetByValue(name-1) found a value deleted by key")
+	}
+	if _, err := bi.GetByKey("id-2"); err == nil {
+		t.Errorf("GetByKey(id-2) found a key deleted by value")
+	}
+	if err := bi.DeleteByKey("id-1"); err == nil {
+		t.Errorf("DeleteByKey(id-1) twice succeeded, expected an error")
+	}
+	if bi.Len() != 498 {
+		t.Errorf("Len() = %d, expected: 498", bi.Len())
+	}
+	if err := bi.Validate(); err != nil {
+		t.Errorf("Validate(): %v", err)
+	}
+}
+
+func TestBiConflictReject(t *testing.T) {
+	bi := NewBi(10, ConflictReject)
+	bi.Insert("a", "1")
+	bi.Insert("b", "2")
+
+	if err := bi.Insert("a", "1"); err != nil {
+		t.Errorf("Insert of an existing pair: %v, expected no error", err)
+	}
+	if err := bi.Insert("a", "3"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Insert(a, 3) = %v, expected ErrConflict for the key", err)
+	}
+	if err := bi.Insert("c", "2"); !errors.Is(err, ErrConflict) {
+		t.Errorf("Insert(c, 2) = %v, expected ErrConflict for the value", err)
+	}
+	if value, _ := bi.GetByKey("a"); value != "1" || bi.Len() != 2 {
+		t.Errorf("GetByKey(a) = %q, Len() = %d, expected the table to be unchanged", value, bi.Len())
+	}
+}
+
+func TestBiConflictReplace(t *testing.T) {
+	bi := NewBi(10, ConflictReplace)
+	bi.Insert("a", "1")
+	bi.Insert("b", "2")
+
+	// a -> 2 conflicts with both pairs, which are replaced by the new one.
+	if err := bi.Insert("a", "2"); err != nil {
+		t.Fatalf("Insert(a, 2): %v", err)
+	}
+	if bi.Len() != 1 {
+		t.Errorf("Len() = %d, expected: 1", bi.Len())
+	}
+	if _, err := bi.GetByKey("b"); err == nil {
+		t.Errorf("GetByKey(b) found the replaced pair b -> 2")
+	}
+	if _, err := bi.GetByValue("1"); err == nil {
+		t.Errorf("GetByValue(1) found the replaced pair a -> 1")
+	}
+	if key, _ := bi.GetByValue("2"); key != "a" {
+		t.Errorf("GetByValue(2) = %q, expected: a", key)
+	}
+	if err := bi.Validate(); err != nil {
+		t.Errorf("Validate(): %v", err)
+	}
+}
+
+func TestBiInvalidValue(t *testing.T) {
+	bi := NewBi(10, ConflictReplace)
+	if err := bi.Insert("a", strings.Repeat("v", maxKeyLength+1)); err == nil {
+		t.Errorf("Insert with a too long value succeeded, expected an error")
+	}
+	if bi.Len() != 0 {
+		t.Errorf("Len() = %d, expected: 0", bi.Len())
+	}
+}
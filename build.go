@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Number of slot ranges Build places keys in. It is fixed, rather than
+// derived from the number of workers, so that the slot each key ends up in
+// doesn't depend on how many goroutines took part.
+const buildPartitions int = 256
+
+type BuildOptions struct {
+	// Workers is the number of goroutines used. Zero means GOMAXPROCS.
+	Workers int
+}
+
+// buildEntry is a hashed key of a Build together with its position in the
+// input slices.
+type buildEntry struct {
+	key   nodeKey
+	home  uint64
+	index int
+}
+
+// Build returns a table holding keys[i] with values[i] for every i. When a key
+// occurs more than once, its last value wins like with consecutive Insert
+// calls.
+//
+// Keys are hashed in parallel and every worker places the keys whose home slot
+// lies in one slot range at a time. A key whose home slot is already taken is
+// left for a sequential pass that inserts these keys in input order, so the
+// slots of the result are the same for any number of workers. The
+// insertions of Build are not counted in Stats.
+func Build[V any](keys []string, values []V, opts BuildOptions) (*HashTable[V], error) {
+	if len(keys) != len(values) {
+		return nil, fmt.Errorf("got %d keys but %d values", len(keys), len(values))
+	}
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return nil, err
+		}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	h := New[V](getPrime(lengthFor(len(keys)), true))
+	entries := make([]buildEntry, len(keys))
+	parallel(workers, len(keys), func(start, end int) {
+		for i := start; i < end; i++ {
+			k := NewKey(keys[i])
+			entries[i] = buildEntry{key: k, home: h.doubleHashing(k, 0), index: i}
+		}
+	})
+
+	// Group the entries by slot range, keeping the input order in each range.
+	partitionLength := (h.length + uint64(buildPartitions) - 1) / uint64(buildPartitions)
+	bounds := make([]int, buildPartitions+1)
+	for _, entry := range entries {
+		bounds[entry.home/partitionLength+1]++
+	}
+	for i := 1; i < len(bounds); i++ {
+		bounds[i] += bounds[i-1]
+	}
+	grouped := make([]buildEntry, len(entries))
+	next := append([]int(nil), bounds[:buildPartitions]...)
+	for _, entry := range entries {
+		partition := entry.home / partitionLength
+		grouped[next[partition]] = entry
+		next[partition]++
+	}
+
+	// Every partition only writes the slots of its own range.
+	placed := make([]uint64, buildPartitions)
+	overflow := make([][]buildEntry, buildPartitions)
+	parallel(workers, buildPartitions, func(start, end int) {
+		for partition := start; partition < end; partition++ {
+			for _, entry := range grouped[bounds[partition]:bounds[partition+1]] {
+				item := &h.slots[entry.home]
+				switch {
+				case item.state == slotEmpty:
+					*item = data[V]{key: entry.key, value: values[entry.index], state: slotOccupied}
+					placed[partition]++
+				case item.key.value == entry.key.value:
+					item.value = values[entry.index]
+				default:
+					overflow[partition] = append(overflow[partition], entry)
+				}
+			}
+		}
+	})
+
+	for _, count := range placed {
+		h.activeSlotCounter += count
+		h.occupiedSlotCounter += count
+	}
+	// The entries of a partition are in input order, but the partitions
+	// aren't, so the overflow is merged back into input order.
+	var rest []buildEntry
+	for _, entries := range overflow {
+		rest = append(rest, entries...)
+	}
+	slices.SortFunc(rest, func(a, b buildEntry) int { return a.index - b.index })
+	for _, entry := range rest {
+		h.insert(h.slots, entry.key, values[entry.index])
+	}
+	return h, nil
+}
+
+// parallel splits [0, n) into one contiguous chunk per worker and calls fn for
+// every chunk in its own goroutine.
+func parallel(workers int, n int, fn func(start, end int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	chunk := (n + workers - 1) / workers
+	for start := 0; start < n; start += chunk {
+		end := min(start+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(start, end)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	keys := makeSequentialKeys(50_000)
+	values := make([]int, len(keys))
+	for i := range values {
+		values[i] = i
+	}
+
+	table, err := Build(keys, values, BuildOptions{})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if table.Len() != len(keys) {
+		t.Errorf("Len() = %d, expected: %d", table.Len(), len(keys))
+	}
+	for i, key := range keys {
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Fatalf("Search(%s) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after Build: %v", err)
+	}
+}
+
+func TestBuildIsDeterministic(t *testing.T) {
+	// Colliding keys make sure that the sequential overflow pass is used.
+	keys := append(makeSequentialKeys(20_000), findCollidingKeys(50, 33343)...)
+	values := make([]int, len(keys))
+	for i := range values {
+		values[i] = i
+	}
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	var reference *HashTable[int]
+	for _, procs := range []int{1, 2, 3, 8} {
+		runtime.GOMAXPROCS(procs)
+		for _, workers := range []int{0, 1, 5, 64} {
+			table, err := Build(keys, values, BuildOptions{Workers: workers})
+			if err != nil {
+				t.Fatalf("Build returned error: %v", err)
+			}
+			if reference == nil {
+				reference = table
+				continue
+			}
+			if !slices.Equal(table.slots, reference.slots) {
+				t.Fatalf("Build with GOMAXPROCS %d and %d workers placed keys differently", procs, workers)
+			}
+		}
+	}
+}
+
+func TestBuildDuplicateKeysLastValueWins(t *testing.T) {
+	keys := []string{"foo-1", "foo-2", "foo-1", "foo-3", "foo-1"}
+	table, err := Build(keys, []int{1, 2, 3, 4, 5}, BuildOptions{Workers: 2})
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	if value, _ := table.Search("foo-1"); value != 5 {
+		t.Errorf("Search(foo-1) = %d, expected the last value: 5", value)
+	}
+	if table.Len() != 3 {
+		t.Errorf("Len() = %d, expected: 3", table.Len())
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	if _, err := Build([]string{"foo-1"}, []int{}, BuildOptions{}); err == nil {
+		t.Errorf("Build with more keys than values = nil error, expected an error")
+	}
+	tooLong := fmt.Sprintf("%0*d", maxKeyLength+1, 1)
+	if _, err := Build([]string{tooLong}, []int{1}, BuildOptions{}); err == nil {
+		t.Errorf("Build with a %d character key = nil error, expected an error", len(tooLong))
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	totalKeys := 1_000_000
+	keys := makeSequentialKeys(totalKeys)
+	values := make([]int, totalKeys)
+	for i := range values {
+		values[i] = i
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Build(keys, values, BuildOptions{}); err != nil {
+			b.Fatalf("Build returned error: %v", err)
+		}
+	}
+}
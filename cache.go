@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CachePolicy selects how a Cache picks the keys it evicts.
+type CachePolicy int
+
+const (
+	// PolicyLRU evicts the least recently used key.
+	PolicyLRU CachePolicy = iota
+	// PolicyCLOCK approximates LRU with a reference bit per key and a clock
+	// hand that gives referenced keys a second chance.
+	PolicyCLOCK
+	// Policy2Q keeps keys seen once in a FIFO queue and only promotes keys
+	// seen again while remembered in a ghost queue to the LRU main queue.
+	Policy2Q
+	// PolicyTinyLFU is W-TinyLFU: a small LRU window in front of a
+	// segmented LRU main area that only admits keys estimated to be used
+	// more often than the key they would replace.
+	PolicyTinyLFU
+)
+
+var cachePolicyNames = map[CachePolicy]string{
+	PolicyLRU:     "lru",
+	PolicyCLOCK:   "clock",
+	Policy2Q:      "2q",
+	PolicyTinyLFU: "tinylfu",
+}
+
+func (p CachePolicy) String() string {
+	if name, ok := cachePolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("CachePolicy(%d)", int(p))
+}
+
+func ParseCachePolicy(name string) (CachePolicy, error) {
+	for policy, policyName := range cachePolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown cache policy %q", name)
+}
+
+// cachePolicy tracks the keys of a Cache and decides which one to evict. The
+// values stay in the Cache's HashTable.
+type cachePolicy interface {
+	// hit records an access to a cached key.
+	hit(key string)
+	// add records a new key. If the cache is over capacity it returns the
+	// key to evict, which may be the new key itself.
+	add(key string) (victim string, evict bool)
+	// remove forgets a key removed from the cache.
+	remove(key string)
+}
+
+func newCachePolicy(policy CachePolicy, capacity int) (cachePolicy, error) {
+	switch policy {
+	case PolicyLRU:
+		return newLRUPolicy(capacity), nil
+	case PolicyCLOCK:
+		return newClockPolicy(capacity), nil
+	case Policy2Q:
+		return newTwoQueuePolicy(capacity), nil
+	case PolicyTinyLFU:
+		return newTinyLFUPolicy(capacity), nil
+	}
+	return nil, fmt.Errorf("unknown cache policy %v", policy)
+}
+
+type CacheOptions[V any] struct {
+	Policy CachePolicy
+	// MaxEntries is the number of entries kept.
+	MaxEntries int
+	// OnEvict, if set, is called for every entry that leaves the cache.
+	OnEvict func(key string, value V, reason EvictionReason)
+}
+
+// Cache is a HashTable bounded to a number of entries, evicting keys as
+// chosen by its CachePolicy. It is not safe for concurrent use.
+type Cache[V any] struct {
+	values *HashTable[V]
+	policy cachePolicy
+	opts   CacheOptions[V]
+	stats  CacheStats
+}
+
+func NewCache[V any](opts CacheOptions[V]) (*Cache[V], error) {
+	if opts.MaxEntries <= 0 {
+		return nil, errors.New("cache needs a positive MaxEntries")
+	}
+	policy, err := newCachePolicy(opts.Policy, opts.MaxEntries)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache[V]{
+		values: New[V](lengthFor(opts.MaxEntries)),
+		policy: policy,
+		opts:   opts,
+	}, nil
+}
+
+func (c *Cache[V]) Len() int {
+	return c.values.Len()
+}
+
+func (c *Cache[V]) Stats() CacheStats {
+	return c.stats
+}
+
+func (c *Cache[V]) evicted(key string, value V, reason EvictionReason) {
+	if c.opts.OnEvict != nil {
+		c.opts.OnEvict(key, value, reason)
+	}
+}
+
+func (c *Cache[V]) Get(key string) (V, bool) {
+	value, err := c.values.Search(key)
+	if err != nil {
+		c.stats.Misses++
+		return value, false
+	}
+	c.stats.Hits++
+	c.policy.hit(key)
+	return value, true
+}
+
+// Put stores value and counts as an access to key. Adding a key may evict
+// another key, or the new key itself when the policy doesn't admit it.
+func (c *Cache[V]) Put(key string, value V) {
+	if old, err := c.values.Search(key); err == nil {
+		c.values.Insert(key, value)
+		c.policy.hit(key)
+		c.evicted(key, old, EvictReplaced)
+		return
+	}
+
+	c.values.Insert(key, value)
+	victim, evict := c.policy.add(key)
+	if !evict {
+		return
+	}
+	victimValue, _ := c.values.Search(victim)
+	c.values.Delete(victim)
+	c.stats.Evictions++
+	reason := EvictCapacity
+	if victim == key {
+		reason = EvictRejected
+	}
+	c.evicted(victim, victimValue, reason)
+}
+
+// Remove deletes key from the cache and reports whether it was cached.
+func (c *Cache[V]) Remove(key string) bool {
+	value, err := c.values.Search(key)
+	if err != nil {
+		return false
+	}
+	c.values.Delete(key)
+	c.policy.remove(key)
+	c.evicted(key, value, EvictRemoved)
+	return true
+}
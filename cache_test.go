@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+var allCachePolicies = []CachePolicy{PolicyLRU, PolicyCLOCK, Policy2Q, PolicyTinyLFU}
+
+func TestParseCachePolicy(t *testing.T) {
+	for _, policy := range allCachePolicies {
+		parsed, err := ParseCachePolicy(policy.String())
+		if err != nil || parsed != policy {
+			t.Errorf("ParseCachePolicy(%s) = %v, %v, expected: %v", policy, parsed, err, policy)
+		}
+	}
+	if _, err := ParseCachePolicy("arc"); err == nil {
+		t.Errorf("ParseCachePolicy(arc) = nil error, expected an error")
+	}
+}
+
+func TestCacheStaysWithinCapacity(t *testing.T) {
+	for _, policy := range allCachePolicies {
+		t.Run(policy.String(), func(t *testing.T) {
+			evictions := 0
+			cache, err := NewCache(CacheOptions[int]{
+				Policy:     policy,
+				MaxEntries: 100,
+				OnEvict: func(key string, value int, reason EvictionReason) {
+					if reason == EvictCapacity || reason == EvictRejected {
+						evictions++
+					}
+				},
+			})
+			if err != nil {
+				t.Fatalf("NewCache returned error: %v", err)
+			}
+
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < 20_000; i++ {
+				key := fmt.Sprintf("key-%d", r.Intn(500))
+				switch r.Intn(10) {
+				case 0:
+					cache.Remove(key)
+				default:
+					if value, ok := cache.Get(key); ok && value != len(key) {
+						t.Fatalf("Get(%s) = %d, expected: %d", key, value, len(key))
+					}
+					cache.Put(key, len(key))
+				}
+				if cache.Len() > 100 {
+					t.Fatalf("Len() = %d after %d operations, expected at most 100", cache.Len(), i)
+				}
+			}
+			if stats := cache.Stats(); stats.Evictions != uint64(evictions) || evictions == 0 {
+				t.Errorf("Stats().Evictions = %d, OnEvict saw %d evictions", stats.Evictions, evictions)
+			}
+		})
+	}
+}
+
+func TestCacheReplacedAndRemoved(t *testing.T) {
+	var reasons []EvictionReason
+	cache, _ := NewCache(CacheOptions[int]{
+		Policy:     Policy2Q,
+		MaxEntries: 10,
+		OnEvict:    func(key string, value int, reason EvictionReason) { reasons = append(reasons, reason) },
+	})
+	cache.Put("a", 1)
+	cache.Put("a", 2)
+	if value, ok := cache.Get("a"); !ok || value != 2 {
+		t.Errorf("Get(a) = %d, %v, expected: 2, true", value, ok)
+	}
+	if !cache.Remove("a") || cache.Remove("a") {
+		t.Errorf("Remove(a) expected to succeed exactly once")
+	}
+	if len(reasons) != 2 || reasons[0] != EvictReplaced || reasons[1] != EvictRemoved {
+		t.Errorf("eviction reasons = %v, expected: [replaced removed]", reasons)
+	}
+}
+
+func TestClockGivesReferencedKeysASecondChance(t *testing.T) {
+	cache, _ := NewCache(CacheOptions[int]{Policy: PolicyCLOCK, MaxEntries: 3})
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Put("c", 3)
+	cache.Get("a")
+	cache.Put("d", 4)
+	if _, ok := cache.Get("a"); !ok {
+		t.Errorf("Get(a) = not found, expected the referenced key to survive")
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Get(b) = found, expected the first unreferenced key to be evicted")
+	}
+}
+
+func TestTinyLFURejectsRareKeys(t *testing.T) {
+	cache, _ := NewCache(CacheOptions[int]{Policy: PolicyTinyLFU, MaxEntries: 100})
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("hot-%d", i)
+			if _, ok := cache.Get(key); !ok {
+				cache.Put(key, i)
+			}
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		cache.Put(fmt.Sprintf("scan-%d", i), i)
+	}
+	hot := 0
+	for i := 0; i < 100; i++ {
+		if _, ok := cache.values.Search(fmt.Sprintf("hot-%d", i)); ok == nil {
+			hot++
+		}
+	}
+	if hot < 95 {
+		t.Errorf("%d of 100 hot keys survived a scan, expected TinyLFU to keep at least 95", hot)
+	}
+}
+
+func TestCountMinSketch(t *testing.T) {
+	sketch := newCountMinSketch(1000)
+	for i := 0; i < 5; i++ {
+		sketch.increment("foo")
+	}
+	if estimate := sketch.estimate("foo"); estimate != 5 {
+		t.Errorf("estimate(foo) = %d, expected: 5", estimate)
+	}
+	for i := 0; i < 100; i++ {
+		sketch.increment("bar")
+	}
+	if estimate := sketch.estimate("bar"); estimate != sketchMaxCount {
+		t.Errorf("estimate(bar) = %d, expected the counters to saturate at %d", estimate, sketchMaxCount)
+	}
+
+	sketch.reset()
+	if foo, bar := sketch.estimate("foo"), sketch.estimate("bar"); foo != 2 || bar != sketchMaxCount/2 {
+		t.Errorf("estimates after reset = %d, %d, expected them to be halved: 2, %d", foo, bar, sketchMaxCount/2)
+	}
+}
+
+func TestNewCacheErrors(t *testing.T) {
+	if _, err := NewCache(CacheOptions[int]{}); err == nil {
+		t.Errorf("NewCache without MaxEntries = nil error, expected an error")
+	}
+	if _, err := NewCache(CacheOptions[int]{Policy: CachePolicy(42), MaxEntries: 10}); err == nil {
+		t.Errorf("NewCache with an unknown policy = nil error, expected an error")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import "errors"
+
+// ColumnarHashTable is a HashTable with a struct-of-arrays slot layout. The
+// states, hashes, keys and values of the slots live in separate parallel
+// arrays, so probing only touches the compact state and hash arrays and reads
+// a key string only once its full hash matches.
+type ColumnarHashTable[V any] struct {
+	length              uint64
+	states              []uint8
+	hashes              []uint64
+	keys                []string
+	values              []V
+	activeSlotCounter   uint64
+	occupiedSlotCounter uint64
+}
+
+func NewColumnar[V any](length uint64) *ColumnarHashTable[V] {
+	primeLength := pickLargestLength(length)
+	c := &ColumnarHashTable[V]{}
+	c.allocate(primeLength)
+	return c
+}
+
+func (c *ColumnarHashTable[V]) allocate(length uint64) {
+	c.length = length
+	c.states = make([]uint8, length)
+	c.hashes = make([]uint64, length)
+	c.keys = make([]string, length)
+	c.values = make([]V, length)
+	c.activeSlotCounter = 0
+	c.occupiedSlotCounter = 0
+}
+
+func (c *ColumnarHashTable[V]) Len() int {
+	return int(c.activeSlotCounter)
+}
+
+func (c *ColumnarHashTable[V]) computeLoadFactor() float32 {
+	return float32(c.occupiedSlotCounter) / float32(c.length)
+}
+
+func (c *ColumnarHashTable[V]) slotState(index uint64) uint8 {
+	return c.states[index]
+}
+
+// slotMatches only reads the key string once the full hash matches.
+func (c *ColumnarHashTable[V]) slotMatches(index uint64, key nodeKey) bool {
+	return c.hashes[index] == key.hash && c.keys[index] == key.value
+}
+
+func (c *ColumnarHashTable[V]) resize(newSize uint64) {
+	states, hashes, keys, values := c.states, c.hashes, c.keys, c.values
+	c.allocate(newSize)
+	for i := range states {
+		if states[i] != slotOccupied {
+			continue
+		}
+		c.insert(hashes[i], keys[i], values[i])
+	}
+}
+
+func (c *ColumnarHashTable[V]) insertItem(index uint64, hash uint64, key string, value V) {
+	if c.states[index] == slotEmpty {
+		c.occupiedSlotCounter++
+	}
+	c.states[index] = slotOccupied
+	c.hashes[index] = hash
+	c.keys[index] = key
+	c.values[index] = value
+	c.activeSlotCounter++
+}
+
+func (c *ColumnarHashTable[V]) insert(hash uint64, key string, value V) {
+	location, found := probeSlots(c, c.length, nodeKey{value: key, hash: hash})
+	switch {
+	case found:
+		c.values[location] = value
+	case location < c.length:
+		c.insertItem(location, hash, key, value)
+	}
+}
+
+func (c *ColumnarHashTable[V]) Insert(key string, value V) {
+	if c.computeLoadFactor() >= risizeUpThreshold {
+		c.resize(nextSizeUp(c.length))
+	}
+	k := NewKey(key)
+	c.insert(k.hash, k.value, value)
+}
+
+// find returns the slot holding key, probing the state and hash arrays only.
+func (c *ColumnarHashTable[V]) find(key string) (uint64, bool) {
+	return probeSlots(c, c.length, NewKey(key))
+}
+
+func (c *ColumnarHashTable[V]) Search(key string) (V, error) {
+	index, ok := c.find(key)
+	if !ok {
+		var zero V
+		return zero, errors.New(keyNotFoundErrorMsg)
+	}
+	return c.values[index], nil
+}
+
+func (c *ColumnarHashTable[V]) Delete(key string) error {
+	index, ok := c.find(key)
+	if !ok {
+		return errors.New(keyNotFoundErrorMsg)
+	}
+
+	var zero V
+	c.states[index] = slotTombstone
+	c.keys[index] = ""
+	c.values[index] = zero
+	c.activeSlotCounter--
+
+	if c.computeLoadFactor() <= resizeDownThreshold && c.length > primes[0] {
+		c.resize(nextSizeDown(c.length))
+	}
+	return nil
+}
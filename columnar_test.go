@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func buildColumnarHashTable(keys []string, tableLength uint64) *ColumnarHashTable[int] {
+	table := NewColumnar[int](tableLength)
+	for i, key := range keys {
+		table.Insert(key, i)
+	}
+	return table
+}
+
+func TestColumnarInsertSearchDelete(t *testing.T) {
+	table := NewColumnar[int](10)
+	key := "foo-1"
+	if _, err := table.Search(key); err == nil {
+		t.Errorf("Search(%s) on empty table = nil error, expected key not found", key)
+	}
+	table.Insert(key, 100)
+	table.Insert(key, 200)
+	value, err := table.Search(key)
+	if err != nil || value != 200 {
+		t.Errorf("Search(%s) = %d, want 200, error: %v", key, value, err)
+	}
+	if table.Len() != 1 {
+		t.Errorf("Len() = %d, expected: 1", table.Len())
+	}
+	if err := table.Delete(key); err != nil {
+		t.Errorf("Delete(%s) returned error: %v", key, err)
+	}
+	if err := table.Delete(key); err == nil {
+		t.Errorf("Delete(%s) twice = nil, expected an error", key)
+	}
+}
+
+func BenchmarkColumnarSearchExistingKey(b *testing.B) {
+	totalItems := 1_000_000
+	keys := makeSequentialKeys(totalItems)
+	targetKey := keys[totalItems/2]
+	table := buildColumnarHashTable(keys, 2_000_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		value, err := table.Search(targetKey)
+		if err != nil || value != totalItems/2 {
+			b.Fatalf(`Search(%s) expected %d, got value=%v error=%v`, targetKey, totalItems/2, value, err)
+		}
+	}
+}
+
+func BenchmarkColumnarSearchNonExistingKey(b *testing.B) {
+	totalItems := 1_000_000
+	keys := makeSequentialKeys(totalItems)
+	table := buildColumnarHashTable(keys, 2_000_000)
+	missingKey := "key-missing"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		value, err := table.Search(missingKey)
+		if err == nil || value != 0 {
+			b.Fatalf(`Search(%s) expected not found, got value=%v error=%v`, missingKey, value, err)
+		}
+	}
+}
+
+func BenchmarkColumnarProbingHeavyCollisionSearch(b *testing.B) {
+	var tableLength uint64 = 389
+	keys := findCollidingKeys(200, tableLength)
+	table := buildColumnarHashTable(keys, tableLength)
+	target := keys[len(keys)-1]
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v, err := table.Search(target)
+		if err != nil || v != len(keys)-1 {
+			b.Fatalf("Search(%s) expected %d, got value=%d error=%v", target, len(keys)-1, v, err)
+		}
+	}
+}
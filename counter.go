@@ -0,0 +1,219 @@
+package main
+
+import (
+	"cmp"
+	"container/heap"
+	"slices"
+)
+
+// CounterEntry is a key and its count as returned by Counter.MostCommon.
+type CounterEntry struct {
+	Key   string
+	Count int64
+}
+
+// compareEntries orders entries by descending count, ties by key.
+func compareEntries(a, b CounterEntry) int {
+	if c := cmp.Compare(b.Count, a.Count); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Key, b.Key)
+}
+
+// Counter counts occurrences of keys. An unbounded Counter keeps every key it
+// has seen; a bounded one runs the space-saving algorithm and keeps only the
+// keys with the highest counts.
+type Counter struct {
+	counts *HashTable[int64]
+	total  int64
+	// heavy is set for bounded counters.
+	heavy *spaceSaving
+}
+
+func NewCounter(length uint64) *Counter {
+	return &Counter{counts: New[int64](length)}
+}
+
+// NewBoundedCounter returns a counter that keeps at most capacity keys. When
+// a new key arrives at a full counter it replaces the key with the lowest
+// count and inherits that count, so counts may be overestimated by at most
+// the value reported by Error, but every key counted more than Total() /
+// capacity times is guaranteed to be kept.
+func NewBoundedCounter(capacity int) *Counter {
+	if capacity < 1 {
+		panic("a bounded Counter needs a positive capacity")
+	}
+	return &Counter{
+		counts: New[int64](lengthFor(capacity)),
+		heavy: &spaceSaving{
+			positions: New[int](lengthFor(capacity)),
+			capacity:  capacity,
+		},
+	}
+}
+
+// Len returns the number of counted keys.
+func (c *Counter) Len() int {
+	return c.counts.Len()
+}
+
+// Total returns the sum of all deltas added to the counter, including those
+// of keys a bounded counter no longer keeps.
+func (c *Counter) Total() int64 {
+	return c.total
+}
+
+// Count returns the count of key, 0 if it is not counted.
+func (c *Counter) Count(key string) int64 {
+	if count := c.counts.lookup(key); count != nil {
+		return *count
+	}
+	return 0
+}
+
+// Error returns how much the count of key may be overestimated. It is always
+// 0 for unbounded counters.
+func (c *Counter) Error(key string) int64 {
+	if c.heavy == nil {
+		return 0
+	}
+	if i := c.heavy.positions.lookup(key); i != nil {
+		return c.heavy.items[*i].overestimate
+	}
+	return 0
+}
+
+// Inc adds delta to the count of key and returns the new count. On an
+// unbounded counter it probes the table once. Bounded counters only accept
+// positive deltas.
+func (c *Counter) Inc(key string, delta int64) int64 {
+	// Checked before any state changes, so a recovered panic leaves the
+	// counter intact.
+	if c.heavy != nil && delta <= 0 {
+		panic("a bounded Counter only accepts positive deltas")
+	}
+	c.total += delta
+	return c.add(key, delta)
+}
+
+// add adds delta to the count of key without updating the total.
+func (c *Counter) add(key string, delta int64) int64 {
+	if c.heavy != nil {
+		return c.addBounded(key, delta)
+	}
+	count := c.counts.upsert(key)
+	*count += delta
+	return *count
+}
+
+// addBounded adds a positive delta to the count of key.
+func (c *Counter) addBounded(key string, delta int64) int64 {
+	s := c.heavy
+	if i := s.positions.lookup(key); i != nil {
+		s.items[*i].count += delta
+		count := s.items[*i].count
+		// Fix moves the item, so its count is read before.
+		heap.Fix(s, *i)
+		*c.counts.lookup(key) = count
+		return count
+	}
+
+	if len(s.items) < s.capacity {
+		heap.Push(s, heavyHitter{key: key, count: delta})
+		c.counts.Insert(key, delta)
+		return delta
+	}
+	// Replace the key with the lowest count, which is at the root.
+	minimum := s.items[0]
+	s.positions.Delete(minimum.key)
+	c.counts.Delete(minimum.key)
+	s.items[0] = heavyHitter{key: key, count: minimum.count + delta, overestimate: minimum.count}
+	s.positions.Insert(key, 0)
+	heap.Fix(s, 0)
+	c.counts.Insert(key, minimum.count+delta)
+	return minimum.count + delta
+}
+
+// MostCommon returns the k keys with the highest counts, highest first. Ties
+// are ordered by key.
+func (c *Counter) MostCommon(k int) []CounterEntry {
+	if k <= 0 {
+		return nil
+	}
+	// Keep the k best entries seen so far in a heap whose root is the worst
+	// of them.
+	top := &entryHeap{}
+	for key, count := range c.counts.All() {
+		entry := CounterEntry{Key: key, Count: count}
+		if top.Len() < k {
+			heap.Push(top, entry)
+		} else if compareEntries(entry, (*top)[0]) < 0 {
+			(*top)[0] = entry
+			heap.Fix(top, 0)
+		}
+	}
+	entries := []CounterEntry(*top)
+	slices.SortFunc(entries, compareEntries)
+	return entries
+}
+
+// Merge adds the counts and the total of other to c. A bounded c skips keys
+// whose count in other is not positive.
+func (c *Counter) Merge(other *Counter) {
+	for key, count := range other.counts.All() {
+		if c.heavy != nil && count <= 0 {
+			continue
+		}
+		c.add(key, count)
+	}
+	c.total += other.total
+}
+
+// entryHeap is a heap of CounterEntry with the entry that compareEntries puts
+// last at the root.
+type entryHeap []CounterEntry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return compareEntries(h[i], h[j]) > 0 }
+func (h entryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x any)        { *h = append(*h, x.(CounterEntry)) }
+func (h *entryHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+type heavyHitter struct {
+	key   string
+	count int64
+	// overestimate is the count inherited from the key this one replaced.
+	overestimate int64
+}
+
+// spaceSaving is the min-heap of the keys of a bounded Counter, with the heap
+// position of every key so its count can be updated in place.
+type spaceSaving struct {
+	items     []heavyHitter
+	positions *HashTable[int]
+	capacity  int
+}
+
+func (s *spaceSaving) Len() int           { return len(s.items) }
+func (s *spaceSaving) Less(i, j int) bool { return s.items[i].count < s.items[j].count }
+func (s *spaceSaving) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	*s.positions.lookup(s.items[i].key) = i
+	*s.positions.lookup(s.items[j].key) = j
+}
+func (s *spaceSaving) Push(x any) {
+	item := x.(heavyHitter)
+	s.positions.Insert(item.key, len(s.items))
+	s.items = append(s.items, item)
+}
+func (s *spaceSaving) Pop() any {
+	item := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	s.positions.Delete(item.key)
+	return item
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestCounterInc(t *testing.T) {
+	counter := NewCounter(10)
+	for i := 0; i < 1000; i++ {
+		counter.Inc(fmt.Sprintf("word-%d", i%50), 1)
+	}
+	if got := counter.Inc("word-7", 5); got != 25 {
+		t.Errorf("Inc(word-7, 5) = %d, expected: 25", got)
+	}
+	if got := counter.Inc("word-8", -20); got != 0 {
+		t.Errorf("Inc(word-8, -20) = %d, expected: 0", got)
+	}
+	if counter.Count("word-7") != 25 || counter.Count("missing") != 0 {
+		t.Errorf("Count(word-7) = %d, Count(missing) = %d, expected: 25, 0", counter.Count("word-7"), counter.Count("missing"))
+	}
+	if counter.Len() != 50 || counter.Total() != 985 {
+		t.Errorf("Len() = %d, Total() = %d, expected: 50, 985", counter.Len(), counter.Total())
+	}
+}
+
+func TestCounterMostCommon(t *testing.T) {
+	counter := NewCounter(10)
+	for i := 0; i < 100; i++ {
+		counter.Inc(fmt.Sprintf("key-%02d", i), int64(i%10))
+	}
+
+	got := counter.MostCommon(3)
+	expected := []CounterEntry{{"key-09", 9}, {"key-19", 9}, {"key-29", 9}}
+	if !slices.Equal(got, expected) {
+		t.Errorf("MostCommon(3) = %v, expected: %v", got, expected)
+	}
+	if got := counter.MostCommon(1000); len(got) != 100 || got[0].Count != 9 || got[99].Count != 0 {
+		t.Errorf("MostCommon(1000) returned %d entries from %d down to %d, expected all 100 from 9 down to 0", len(got), got[0].Count, got[len(got)-1].Count)
+	}
+	if got := counter.MostCommon(0); got != nil {
+		t.Errorf("MostCommon(0) = %v, expected nil", got)
+	}
+}
+
+func TestCounterMerge(t *testing.T) {
+	a := NewCounter(10)
+	b := NewCounter(10)
+	a.Inc("x", 1)
+	a.Inc("y", 2)
+	b.Inc("y", 3)
+	b.Inc("z", 4)
+
+	a.Merge(b)
+	for key, expected := range map[string]int64{"x": 1, "y": 5, "z": 4} {
+		if got := a.Count(key); got != expected {
+			t.Errorf("Count(%s) = %d, expected: %d", key, got, expected)
+		}
+	}
+	if a.Total() != 10 || b.Total() != 7 {
+		t.Errorf("Total() = %d and %d, expected: 10 and the merged counter unchanged: 7", a.Total(), b.Total())
+	}
+}
+
+func TestBoundedCounterHeavyHitters(t *testing.T) {
+	const capacity = 50
+	counter := NewBoundedCounter(capacity)
+	random := rand.New(rand.NewSource(1))
+	exact := map[string]int64{}
+	for i := 0; i < 100000; i++ {
+		// A tenth of the stream goes to five heavy keys, the rest is spread
+		// over many rare ones.
+		key := fmt.Sprintf("rare-%d", random.Intn(20000))
+		if i%10 == 0 {
+			key = fmt.Sprintf("heavy-%d", random.Intn(5))
+		}
+		counter.Inc(key, 1)
+		exact[key]++
+	}
+
+	if counter.Len() != capacity || counter.heavy.positions.Len() != capacity {
+		t.Errorf("Len() = %d, expected the counter to keep %d keys", counter.Len(), capacity)
+	}
+	if counter.Total() != 100000 {
+		t.Errorf("Total() = %d, expected: 100000", counter.Total())
+	}
+	top := counter.MostCommon(5)
+	for _, entry := range top {
+		if entry.Key[:5] != "heavy" {
+			t.Errorf("MostCommon(5) = %v, expected only heavy keys", top)
+			break
+		}
+	}
+	for key, count := range counter.counts.All() {
+		if count < exact[key] || count-counter.Error(key) > exact[key] {
+			t.Errorf("%s: count %d with error %d does not bound the exact count %d", key, count, counter.Error(key), exact[key])
+		}
+	}
+}
+
+func TestBoundedCounterHeapPositions(t *testing.T) {
+	counter := NewBoundedCounter(8)
+	for i := 0; i < 1000; i++ {
+		counter.Inc(fmt.Sprintf("key-%d", i*7%23), int64(i%3+1))
+	}
+	for i, item := range counter.heavy.items {
+		if position, _ := counter.heavy.positions.Search(item.key); position != i {
+			t.Errorf("position of %s = %d, expected: %d", item.key, position, i)
+		}
+		if count := counter.Count(item.key); count != item.count {
+			t.Errorf("Count(%s) = %d, expected the heap count: %d", item.key, count, item.count)
+		}
+		if parent := (i - 1) / 2; i > 0 && counter.heavy.items[parent].count > item.count {
+			t.Errorf("heap order broken at %d", i)
+		}
+	}
+}
+
+func TestBoundedCounterIncMovesItem(t *testing.T) {
+	counter := NewBoundedCounter(4)
+	counter.Inc("a", 1)
+	counter.Inc("b", 5)
+	counter.Inc("c", 6)
+	counter.Inc("d", 7)
+
+	// The increment moves a down the heap, past b.
+	if got := counter.Inc("a", 10); got != 11 {
+		t.Errorf("Inc(a, 10) = %d, expected: 11", got)
+	}
+	if got := counter.Count("a"); got != 11 {
+		t.Errorf("Count(a) = %d, expected: 11", got)
+	}
+	if got := counter.Count("b"); got != 5 {
+		t.Errorf("Count(b) = %d, expected: 5", got)
+	}
+	if top := counter.MostCommon(1); len(top) != 1 || top[0] != (CounterEntry{"a", 11}) {
+		t.Errorf("MostCommon(1) = %v, expected: [{a 11}]", top)
+	}
+}
+
+func TestBoundedCounterRejectsNonPositiveDelta(t *testing.T) {
+	counter := NewBoundedCounter(2)
+	counter.Inc("a", 3)
+	for _, delta := range []int64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Inc(a, %d) did not panic", delta)
+				}
+			}()
+			counter.Inc("a", delta)
+		}()
+	}
+	if counter.Total() != 3 || counter.Count("a") != 3 {
+		t.Errorf("Total() = %d, Count(a) = %d after rejected deltas, expected: 3, 3", counter.Total(), counter.Count("a"))
+	}
+}
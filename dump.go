@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+type DumpFormat uint8
+
+const (
+	// DumpText lists every slot with its index, state, key, hash, home slot
+	// and probe distance.
+	DumpText DumpFormat = iota
+	// DumpSVG renders the slots as a heatmap. Occupied slots are colored from
+	// green to red by their probe distance from the home slot.
+	DumpSVG
+)
+
+// Side of a heatmap cell in pixels.
+const dumpCellSize int = 12
+
+// Probe distance at which a heatmap cell reaches the hottest color.
+const dumpHottestDistance uint64 = 8
+
+func ParseDumpFormat(format string) (DumpFormat, error) {
+	switch format {
+	case "text":
+		return DumpText, nil
+	case "svg":
+		return DumpSVG, nil
+	}
+	return 0, fmt.Errorf("unknown dump format %q", format)
+}
+
+// probeDistance returns the number of collisions the key went through before
+// landing on index.
+func (h *HashTable[V]) probeDistance(key nodeKey, index uint64) uint64 {
+	for collisionCount := uint64(0); collisionCount < h.length; collisionCount++ {
+		if h.doubleHashing(key, collisionCount) == index {
+			return collisionCount
+		}
+	}
+	return h.length
+}
+
+func slotStateName(state uint8) string {
+	switch state {
+	case slotOccupied:
+		return "occupied"
+	case slotTombstone:
+		return "tombstone"
+	}
+	return "empty"
+}
+
+// Dump writes a visualization of the slots array to w.
+func (h *HashTable[V]) Dump(w io.Writer, format DumpFormat) error {
+	switch format {
+	case DumpText:
+		return h.dumpText(w)
+	case DumpSVG:
+		return h.dumpSVG(w)
+	}
+	return fmt.Errorf("unknown dump format %d", format)
+}
+
+func (h *HashTable[V]) dumpText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INDEX\tSTATE\tKEY\tHASH\tHOME\tDISTANCE")
+	for i := range h.slots {
+		item := &h.slots[i]
+		if item.state != slotOccupied {
+			fmt.Fprintf(tw, "%d\t%s\t\t\t\t\n", i, slotStateName(item.state))
+			continue
+		}
+		home := h.doubleHashing(item.key, 0)
+		distance := h.probeDistance(item.key, uint64(i))
+		fmt.Fprintf(tw, "%d\t%s\t%q\t%016x\t%d\t%d\n", i, slotStateName(item.state), item.key.value, item.key.hash, home, distance)
+	}
+	return tw.Flush()
+}
+
+// heatColor maps a probe distance to a color going from green to red.
+func heatColor(distance uint64) string {
+	if distance > dumpHottestDistance {
+		distance = dumpHottestDistance
+	}
+	hue := 120 - 120*float64(distance)/float64(dumpHottestDistance)
+	return fmt.Sprintf("hsl(%.0f,80%%,45%%)", hue)
+}
+
+func (h *HashTable[V]) dumpSVG(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	columns := int(math.Ceil(math.Sqrt(float64(h.length))))
+	rows := (int(h.length) + columns - 1) / columns
+	width := columns * dumpCellSize
+	height := rows * dumpCellSize
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+	for i := range h.slots {
+		item := &h.slots[i]
+		x := (i % columns) * dumpCellSize
+		y := (i / columns) * dumpCellSize
+
+		var color, title string
+		switch item.state {
+		case slotEmpty:
+			color = "#eeeeee"
+			title = fmt.Sprintf("%d: empty", i)
+		case slotTombstone:
+			color = "#444444"
+			title = fmt.Sprintf("%d: tombstone", i)
+		default:
+			distance := h.probeDistance(item.key, uint64(i))
+			color = heatColor(distance)
+			title = fmt.Sprintf("%d: %s (home %d, distance %d)", i, item.key.value, h.doubleHashing(item.key, 0), distance)
+		}
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"><title>`, x, y, dumpCellSize, dumpCellSize, color)
+		xmlEscape(bw, title)
+		fmt.Fprintln(bw, `</title></rect>`)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+func xmlEscape(w *bufio.Writer, text string) {
+	for _, r := range text {
+		switch r {
+		case '<':
+			w.WriteString("&lt;")
+		case '>':
+			w.WriteString("&gt;")
+		case '&':
+			w.WriteString("&amp;")
+		case '"':
+			w.WriteString("&quot;")
+		default:
+			w.WriteRune(r)
+		}
+	}
+}
+
+// runDump implements the dump command: it builds a table from a file with one
+// key per line and writes its visualization.
+func runDump(args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ContinueOnError)
+	keysPath := flags.String("keys", "", "file with one key per line, - reads from stdin")
+	formatName := flags.String("format", "svg", "output format: svg or text")
+	outPath := flags.String("out", "", "output file, defaults to stdout")
+	length := flags.Uint64("length", 0, "initial table length")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keysPath == "" {
+		return errors.New("dump: -keys is required")
+	}
+	format, err := ParseDumpFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	var keys io.Reader = os.Stdin
+	if *keysPath != "-" {
+		file, err := os.Open(*keysPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		keys = file
+	}
+
+	table := New[int](*length)
+	scanner := bufio.NewScanner(keys)
+	for line := 0; scanner.Scan(); line++ {
+		key := scanner.Text()
+		if err := validateKey(key); err != nil {
+			return fmt.Errorf("line %d: %w", line+1, err)
+		}
+		table.Insert(key, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if *outPath == "" {
+		return table.Dump(os.Stdout, format)
+	}
+	out, err := os.Create(*outPath)
+	if err != nil {
+		return err
+	}
+	if err := table.Dump(out, format); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDumpText(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	hashTable.Insert("foo-2", 2)
+	hashTable.Insert("foo-3", 3)
+	hashTable.Delete("foo-2")
+
+	var buf bytes.Buffer
+	if err := hashTable.Dump(&buf, DumpText); err != nil {
+		t.Fatalf("Dump returned error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != int(hashTable.length)+1 {
+		t.Fatalf("Dump wrote %d lines, expected a header and %d slots", len(lines), hashTable.length)
+	}
+	output := buf.String()
+	for _, expected := range []string{`"foo-1"`, `"foo-3"`, "tombstone", "empty"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Dump output is missing %s:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, `"foo-2"`) {
+		t.Errorf("Dump output lists deleted key foo-2:\n%s", output)
+	}
+}
+
+func TestProbeDistance(t *testing.T) {
+	var tableLength uint64 = 389
+	keys := findCollidingKeys(5, tableLength)
+	hashTable := buildHashTable(keys, tableLength)
+
+	for i := range hashTable.slots {
+		item := &hashTable.slots[i]
+		if item.state != slotOccupied {
+			continue
+		}
+		distance := hashTable.probeDistance(item.key, uint64(i))
+		if hashTable.doubleHashing(item.key, distance) != uint64(i) {
+			t.Errorf("probeDistance(%s, %d) = %d does not lead back to the slot", item.key.value, i, distance)
+		}
+		if item.key.value == keys[0] && distance != 0 {
+			t.Errorf("probeDistance of the first inserted key = %d, expected: 0", distance)
+		}
+	}
+}
+
+func TestDumpSVGIsWellFormed(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert(`<foo&"bar">`, 1)
+	hashTable.Insert("foo-2", 2)
+	hashTable.Delete("foo-2")
+
+	var buf bytes.Buffer
+	if err := hashTable.Dump(&buf, DumpSVG); err != nil {
+		t.Fatalf("Dump returned error: %v", err)
+	}
+	decoder := xml.NewDecoder(&buf)
+	rects := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "rect" {
+			rects++
+		}
+	}
+	if rects != int(hashTable.length) {
+		t.Errorf("SVG has %d rects, expected one per slot: %d", rects, hashTable.length)
+	}
+}
+
+func TestRunDump(t *testing.T) {
+	dir := t.TempDir()
+	keysPath := filepath.Join(dir, "keys.txt")
+	outPath := filepath.Join(dir, "table.txt")
+	os.WriteFile(keysPath, []byte("foo-1\nfoo-2\nfoo-3\n"), 0o644)
+
+	if err := runDump([]string{"-keys", keysPath, "-format", "text", "-out", outPath}); err != nil {
+		t.Fatalf("runDump returned error: %v", err)
+	}
+	output, _ := os.ReadFile(outPath)
+	for _, key := range []string{"foo-1", "foo-2", "foo-3"} {
+		if !strings.Contains(string(output), key) {
+			t.Errorf("dump output is missing key %s", key)
+		}
+	}
+
+	if err := runDump([]string{"-keys", keysPath, "-format", "png"}); err == nil {
+		t.Errorf("runDump with an unknown format = nil, expected an error")
+	}
+}
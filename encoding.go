@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// gobEntry is the wire representation of a single key-value pair when a
+// HashTable is gob encoded.
+type gobEntry[V any] struct {
+	Key   string
+	Value V
+}
+
+// lengthFor returns a table length that can hold count items without
+// crossing the resize up threshold.
+func lengthFor(count int) uint64 {
+	return uint64(float32(count)/risizeUpThreshold) + 1
+}
+
+func validateKey(key string) error {
+	if len(key) > maxKeyLength {
+		return fmt.Errorf("key %q is longer than %d characters", key, maxKeyLength)
+	}
+	return nil
+}
+
+// WriteJSON streams the table to w as a JSON object where every key of the
+// table becomes an object member. Entries are written one at a time, so the
+// whole document is never held in memory.
+func (h *HashTable[V]) WriteJSON(w io.Writer) error {
+	return writeJSONObject(w, h.All())
+}
+
+// writeJSONObject writes entries to w as the members of a JSON object, in the
+// order of the iterator.
+func writeJSONObject[V any](w io.Writer, entries iter.Seq2[string, V]) error {
+	// bufio.Writer errors are sticky, so they are reported once by Flush.
+	bw := bufio.NewWriter(w)
+	bw.WriteByte('{')
+
+	first := true
+	for entryKey, entryValue := range entries {
+		if !first {
+			bw.WriteByte(',')
+		}
+		first = false
+
+		key, err := json.Marshal(entryKey)
+		if err != nil {
+			return err
+		}
+		value, err := json.Marshal(entryValue)
+		if err != nil {
+			return fmt.Errorf("encoding value of key %q: %w", entryKey, err)
+		}
+		bw.Write(key)
+		bw.WriteByte(':')
+		bw.Write(value)
+	}
+
+	bw.WriteByte('}')
+	return bw.Flush()
+}
+
+// ReadJSON decodes a JSON object from r, inserting every member into the
+// table. Like encoding/json does for maps, existing entries are kept and
+// entries with the same key are overwritten. A JSON null leaves the table untouched.
+func (h *HashTable[V]) ReadJSON(r io.Reader) error {
+	return readJSONObject(r, func() {
+		if h.length == 0 {
+			lookupStats := h.lookupStats
+			*h = *New[V](0)
+			h.lookupStats = lookupStats
+		}
+	}, h.Insert)
+}
+
+// readJSONObject decodes a JSON object from r and calls insert for every
+// member in document order. start is called once the object begins, so it is
+// not called for a JSON null.
+func readJSONObject[V any](r io.Reader, start func(), insert func(key string, value V)) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("expected JSON object, got %v", tok)
+	}
+	start()
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if err := validateKey(key); err != nil {
+			return err
+		}
+
+		var value V
+		if err := dec.Decode(&value); err != nil {
+			return fmt.Errorf("decoding value of key %q: %w", key, err)
+		}
+		insert(key, value)
+	}
+
+	// Consume the closing brace.
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (h *HashTable[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := h.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (h *HashTable[V]) UnmarshalJSON(b []byte) error {
+	return h.ReadJSON(bytes.NewReader(b))
+}
+
+func (h *HashTable[V]) GobEncode() ([]byte, error) {
+	entries := make([]gobEntry[V], 0, h.activeSlotCounter)
+	for i := range h.slots {
+		item := &h.slots[i]
+		if item.state != slotOccupied {
+			continue
+		}
+		entries = append(entries, gobEntry[V]{Key: item.key.value, Value: item.value})
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the contents of the table with the decoded entries. The
+// table is sized up front so that no resize happens while decoding.
+func (h *HashTable[V]) GobDecode(b []byte) error {
+	var entries []gobEntry[V]
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&entries); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := validateKey(entry.Key); err != nil {
+			return err
+		}
+	}
+
+	filter, lookupStats := h.filter, h.lookupStats
+	*h = *New[V](lengthFor(len(entries)))
+	h.lookupStats = lookupStats
+	if filter != nil {
+		h.EnableFilter(filter.bitsPerKey)
+	}
+	for _, entry := range entries {
+		h.Insert(entry.Key, entry.Value)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type encodingPoint struct {
+	X, Y  int
+	Label string
+}
+
+func assertRoundTrip[V any](t *testing.T, source *HashTable[V], decoded *HashTable[V], keys []string) {
+	t.Helper()
+	if decoded.activeSlotCounter != source.activeSlotCounter {
+		t.Fatalf("decoded table has %d items, expected: %d", decoded.activeSlotCounter, source.activeSlotCounter)
+	}
+	for _, key := range keys {
+		want, _ := source.Search(key)
+		got, err := decoded.Search(key)
+		if err != nil {
+			t.Fatalf("Search(%s) on decoded table returned error: %v", key, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%s) = %v, expected: %v", key, got, want)
+		}
+	}
+}
+
+func testEncodingRoundTrip[V any](t *testing.T, makeValue func(i int) V) {
+	keys := makeSequentialKeys(500)
+	source := New[V](10)
+	for i, key := range keys {
+		source.Insert(key, makeValue(i))
+	}
+
+	t.Run("json", func(t *testing.T) {
+		encoded, err := json.Marshal(source)
+		if err != nil {
+			t.Fatalf("json.Marshal returned error: %v", err)
+		}
+		var decoded HashTable[V]
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			t.Fatalf("json.Unmarshal returned error: %v", err)
+		}
+		assertRoundTrip(t, source, &decoded, keys)
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(source); err != nil {
+			t.Fatalf("gob Encode returned error: %v", err)
+		}
+		var decoded HashTable[V]
+		if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+			t.Fatalf("gob Decode returned error: %v", err)
+		}
+		assertRoundTrip(t, source, &decoded, keys)
+	})
+}
+
+func TestEncodingRoundTripInt(t *testing.T) {
+	testEncodingRoundTrip(t, func(i int) int { return i * 2 })
+}
+
+func TestEncodingRoundTripString(t *testing.T) {
+	testEncodingRoundTrip(t, func(i int) string { return fmt.Sprintf("value-%d", i) })
+}
+
+func TestEncodingRoundTripStruct(t *testing.T) {
+	testEncodingRoundTrip(t, func(i int) encodingPoint {
+		return encodingPoint{X: i, Y: -i, Label: fmt.Sprintf("point-%d", i)}
+	})
+}
+
+func TestEncodingRoundTripPointer(t *testing.T) {
+	testEncodingRoundTrip(t, func(i int) *encodingPoint {
+		return &encodingPoint{X: i, Y: i * i, Label: "ptr"}
+	})
+}
+
+func TestMarshalJSONObjectForm(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	encoded, err := json.Marshal(hashTable)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	if string(encoded) != `{"foo-1":1}` {
+		t.Errorf("json.Marshal = %s, expected: %s", encoded, `{"foo-1":1}`)
+	}
+
+	empty := New[int](10)
+	encoded, _ = json.Marshal(empty)
+	if string(encoded) != `{}` {
+		t.Errorf("json.Marshal of empty table = %s, expected: {}", encoded)
+	}
+}
+
+func TestReadJSONKeepsExistingEntries(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	hashTable.Insert("foo-2", 2)
+
+	err := hashTable.ReadJSON(strings.NewReader(`{"foo-2": 20, "foo-3": 30}`))
+	if err != nil {
+		t.Fatalf("ReadJSON returned error: %v", err)
+	}
+	for key, want := range map[string]int{"foo-1": 1, "foo-2": 20, "foo-3": 30} {
+		got, err := hashTable.Search(key)
+		if err != nil || got != want {
+			t.Errorf("Search(%s) = %d, expected: %d, error: %v", key, got, want, err)
+		}
+	}
+}
+
+func TestReadJSONInvalidInput(t *testing.T) {
+	inputs := []string{
+		`[1, 2]`,
+		`{"foo-1": "not a number"}`,
+		`{"foo-1": 1`,
+		fmt.Sprintf(`{"%s": 1}`, strings.Repeat("k", maxKeyLength+1)),
+	}
+	for _, input := range inputs {
+		hashTable := New[int](10)
+		if err := hashTable.ReadJSON(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadJSON(%s) = nil, expected an error", input)
+		}
+	}
+}
+
+func TestWriteJSONStreamsToWriter(t *testing.T) {
+	keys := makeSequentialKeys(1000)
+	hashTable := buildHashTable(keys, 10)
+
+	var buf bytes.Buffer
+	if err := hashTable.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON returned error: %v", err)
+	}
+	decoded := New[int](10)
+	if err := decoded.ReadJSON(&buf); err != nil {
+		t.Fatalf("ReadJSON returned error: %v", err)
+	}
+	assertRoundTrip(t, hashTable, decoded, keys)
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock tells an ExpiringHashTable the current time. Tests can use a clock
+// they advance by hand instead of sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// The timer wheel has wheelLevels levels of wheelSlots slots each. A slot of
+// level l spans wheelSlots^l ticks, so the wheel covers wheelSlots^wheelLevels
+// ticks, about 194 days with the default one second resolution. Timers further
+// out are parked in the top level until they come into range.
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelLevels = 4
+)
+
+type wheelTimer struct {
+	key      string
+	deadline uint64
+}
+
+// timerWheel is a hierarchical timing wheel. Timers are put into the lowest
+// level whose slot span still separates their deadline from the current
+// tick and are cascaded down a level whenever the wheel reaches their slot.
+type timerWheel struct {
+	current uint64
+	count   int
+	levels  [wheelLevels][wheelSlots][]wheelTimer
+}
+
+// add schedules a timer and returns the tick it fires at. Timers due already
+// fire on the next tick.
+func (w *timerWheel) add(key string, deadline uint64) uint64 {
+	deadline = max(deadline, w.current+1)
+	w.place(wheelTimer{key: key, deadline: deadline})
+	return deadline
+}
+
+// place puts timer into its slot. A timer due at the current tick goes into
+// the level 0 slot that advance processes next.
+func (w *timerWheel) place(timer wheelTimer) {
+	deadline := timer.deadline
+	level := 0
+	for level < wheelLevels-1 && deadline>>(wheelBits*(level+1)) != w.current>>(wheelBits*(level+1)) {
+		level++
+	}
+	slot := (deadline >> (wheelBits * level)) & (wheelSlots - 1)
+	w.levels[level][slot] = append(w.levels[level][slot], timer)
+	w.count++
+}
+
+// advance moves the wheel to tick to and calls fire for every timer whose
+// deadline has been reached.
+func (w *timerWheel) advance(to uint64, fire func(key string, deadline uint64)) {
+	for w.current < to {
+		if w.count == 0 {
+			w.current = to
+			return
+		}
+		w.current++
+
+		// Cascade the higher levels first, their timers may land in the
+		// slots cascaded next.
+		for level := wheelLevels - 1; level > 0; level-- {
+			if w.current&(1<<(wheelBits*level)-1) != 0 {
+				continue
+			}
+			slot := (w.current >> (wheelBits * level)) & (wheelSlots - 1)
+			timers := w.levels[level][slot]
+			w.levels[level][slot] = nil
+			w.count -= len(timers)
+			for _, timer := range timers {
+				w.place(timer)
+			}
+		}
+
+		slot := w.current & (wheelSlots - 1)
+		timers := w.levels[0][slot]
+		w.levels[0][slot] = nil
+		w.count -= len(timers)
+		for _, timer := range timers {
+			fire(timer.key, timer.deadline)
+		}
+	}
+}
+
+type expiringEntry[V any] struct {
+	value V
+	// The zero time means the entry never expires.
+	expiresAt time.Time
+	// timerAt is the tick of the pending timer of the key, 0 if there is
+	// none. Timers of the key due at other ticks are stale and ignored.
+	timerAt uint64
+}
+
+func (e *expiringEntry[V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+type ExpiringOptions[V any] struct {
+	// Clock defaults to the system clock.
+	Clock Clock
+	// Resolution is the tick length of the timer wheel. Reap removes a key
+	// within one tick after it expired. It defaults to one second.
+	Resolution time.Duration
+	// OnExpire, if set, is called for every key removed because it expired.
+	OnExpire func(key string, value V)
+}
+
+// ExpiringHashTable is a HashTable whose keys can expire. Search drops an
+// expired key when it finds one, and Reap removes every expired key using a
+// hierarchical timer wheel, so keys that are never searched again don't stay
+// in the table. Reap is not called by the table itself; run it periodically,
+// for example from a time.Ticker.
+type ExpiringHashTable[V any] struct {
+	table *HashTable[expiringEntry[V]]
+	wheel timerWheel
+	opts  ExpiringOptions[V]
+	start time.Time
+}
+
+func NewExpiring[V any](length uint64, opts ExpiringOptions[V]) *ExpiringHashTable[V] {
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = time.Second
+	}
+	return &ExpiringHashTable[V]{
+		table: New[expiringEntry[V]](length),
+		opts:  opts,
+		start: opts.Clock.Now(),
+	}
+}
+
+// Len returns the number of keys, including expired keys not removed yet.
+func (e *ExpiringHashTable[V]) Len() int {
+	return e.table.Len()
+}
+
+// ticks converts t to the number of timer wheel ticks passed by then. The
+// deadline of a key is rounded up instead, so that its timer never fires
+// before the key expired.
+func (e *ExpiringHashTable[V]) ticks(t time.Time, roundUp bool) uint64 {
+	elapsed := t.Sub(e.start)
+	if elapsed <= 0 {
+		return 0
+	}
+	if roundUp {
+		elapsed += e.opts.Resolution - 1
+	}
+	return uint64(elapsed / e.opts.Resolution)
+}
+
+// Insert stores a key that never expires.
+func (e *ExpiringHashTable[V]) Insert(key string, value V) {
+	entry := e.table.upsert(key)
+	*entry = expiringEntry[V]{value: value, timerAt: entry.timerAt}
+}
+
+// InsertWithTTL stores a key that expires once ttl has passed. Inserting the
+// key again replaces its expiration time. A new timer is only scheduled if
+// the key has no pending timer due by then; an earlier timer reschedules
+// itself when it fires before the key expired.
+func (e *ExpiringHashTable[V]) InsertWithTTL(key string, value V, ttl time.Duration) {
+	expiresAt := e.opts.Clock.Now().Add(ttl)
+	deadline := e.ticks(expiresAt, true)
+	entry := e.table.upsert(key)
+	*entry = expiringEntry[V]{value: value, expiresAt: expiresAt, timerAt: entry.timerAt}
+	if entry.timerAt == 0 || entry.timerAt > deadline {
+		entry.timerAt = e.wheel.add(key, deadline)
+	}
+}
+
+func (e *ExpiringHashTable[V]) Search(key string) (V, error) {
+	entry, err := e.table.Search(key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	if entry.expired(e.opts.Clock.Now()) {
+		e.expire(key, entry)
+		var zero V
+		return zero, errors.New(keyNotFoundErrorMsg)
+	}
+	return entry.value, nil
+}
+
+// TTL returns the time left until key expires, or zero if it never expires.
+func (e *ExpiringHashTable[V]) TTL(key string) (time.Duration, error) {
+	entry, err := e.table.Search(key)
+	if err != nil {
+		return 0, err
+	}
+	now := e.opts.Clock.Now()
+	if entry.expired(now) {
+		e.expire(key, entry)
+		return 0, errors.New(keyNotFoundErrorMsg)
+	}
+	if entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return entry.expiresAt.Sub(now), nil
+}
+
+// Delete removes key. The timer of the key stays in the wheel and is
+// ignored when it fires.
+func (e *ExpiringHashTable[V]) Delete(key string) error {
+	return e.table.Delete(key)
+}
+
+func (e *ExpiringHashTable[V]) expire(key string, entry expiringEntry[V]) {
+	e.table.Delete(key)
+	if e.opts.OnExpire != nil {
+		e.opts.OnExpire(key, entry.value)
+	}
+}
+
+// Reap removes every key that has expired by now and returns how many were
+// removed.
+func (e *ExpiringHashTable[V]) Reap() int {
+	now := e.opts.Clock.Now()
+	removed := 0
+	e.wheel.advance(e.ticks(now, false), func(key string, deadline uint64) {
+		// The key may have been deleted, or replaced by an earlier timer
+		// since this one was added.
+		entry := e.table.lookup(key)
+		if entry == nil || entry.timerAt != deadline {
+			return
+		}
+		entry.timerAt = 0
+		switch {
+		case entry.expired(now):
+			e.expire(key, *entry)
+			removed++
+		case !entry.expiresAt.IsZero():
+			// The key was inserted again with a later expiration time.
+			entry.timerAt = e.wheel.add(key, e.ticks(entry.expiresAt, true))
+		}
+	})
+	return removed
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func TestExpiringSearchDropsExpiredKeys(t *testing.T) {
+	clock := newFakeClock()
+	table := NewExpiring[int](10, ExpiringOptions[int]{Clock: clock})
+	table.InsertWithTTL("session", 1, 10*time.Second)
+	table.Insert("forever", 2)
+
+	clock.Advance(9 * time.Second)
+	if value, err := table.Search("session"); err != nil || value != 1 {
+		t.Errorf("Search(session) before expiry = %d, error: %v, expected: 1", value, err)
+	}
+	if ttl, _ := table.TTL("session"); ttl != time.Second {
+		t.Errorf("TTL(session) = %v, expected: 1s", ttl)
+	}
+
+	clock.Advance(time.Second)
+	if _, err := table.Search("session"); err == nil {
+		t.Errorf("Search(session) after expiry = nil error, expected key not found")
+	}
+	if table.Len() != 1 {
+		t.Errorf("Len() = %d, expected Search to remove the expired key", table.Len())
+	}
+
+	clock.Advance(365 * 24 * time.Hour)
+	if ttl, err := table.TTL("forever"); err != nil || ttl != 0 {
+		t.Errorf("TTL(forever) = %v, error: %v, expected a key without expiry", ttl, err)
+	}
+}
+
+func TestExpiringReap(t *testing.T) {
+	clock := newFakeClock()
+	var expired []string
+	table := NewExpiring(10, ExpiringOptions[int]{
+		Clock:      clock,
+		Resolution: time.Millisecond,
+		OnExpire:   func(key string, value int) { expired = append(expired, key) },
+	})
+	table.InsertWithTTL("a", 1, 5*time.Millisecond)
+	table.InsertWithTTL("b", 2, 100*time.Millisecond)
+	table.InsertWithTTL("c", 3, time.Hour)
+	table.InsertWithTTL("deleted", 4, 5*time.Millisecond)
+	table.Delete("deleted")
+	// Inserting again replaces the expiration time.
+	table.InsertWithTTL("renewed", 5, 5*time.Millisecond)
+	table.InsertWithTTL("renewed", 5, time.Hour)
+
+	clock.Advance(4 * time.Millisecond)
+	if removed := table.Reap(); removed != 0 {
+		t.Errorf("Reap() before any key expired removed %d keys", removed)
+	}
+	clock.Advance(time.Millisecond)
+	if removed := table.Reap(); removed != 1 || !slices.Equal(expired, []string{"a"}) {
+		t.Errorf("Reap() removed %d keys (%v), expected: [a]", removed, expired)
+	}
+	clock.Advance(time.Hour)
+	table.Reap()
+	if slices.Sort(expired); !slices.Equal(expired, []string{"a", "b", "c", "renewed"}) {
+		t.Errorf("expired keys = %v, expected: [a b c renewed]", expired)
+	}
+	if table.Len() != 0 {
+		t.Errorf("Len() = %d, expected every key to be reaped", table.Len())
+	}
+}
+
+func TestExpiringReinsertKeepsOneTimer(t *testing.T) {
+	clock := newFakeClock()
+	table := NewExpiring[int](10, ExpiringOptions[int]{Clock: clock})
+	for i := 0; i < 1000; i++ {
+		table.InsertWithTTL("session", i, time.Duration(10+i)*time.Second)
+	}
+	if table.wheel.count != 1 {
+		t.Errorf("wheel.count = %d after extending the TTL, expected: 1", table.wheel.count)
+	}
+
+	// The first timer fires at 10s and moves on to the extended deadline.
+	clock.Advance(10 * time.Second)
+	if removed := table.Reap(); removed != 0 || table.wheel.count != 1 {
+		t.Errorf("Reap() removed %d keys, wheel.count = %d, expected the timer to be rescheduled", removed, table.wheel.count)
+	}
+
+	// A shorter TTL needs an earlier timer, the later one is then ignored.
+	table.InsertWithTTL("session", 1, 5*time.Second)
+	clock.Advance(5 * time.Second)
+	if removed := table.Reap(); removed != 1 {
+		t.Errorf("Reap() removed %d keys, expected the shortened TTL to expire the key", removed)
+	}
+	clock.Advance(time.Hour)
+	if removed := table.Reap(); removed != 0 || table.wheel.count != 0 {
+		t.Errorf("Reap() removed %d keys, wheel.count = %d, expected the stale timer to be dropped", removed, table.wheel.count)
+	}
+}
+
+// Keys with random TTLs spanning every level of the wheel must be reaped in
+// the tick they expire in.
+func TestTimerWheelFiresOnTime(t *testing.T) {
+	var wheel timerWheel
+	r := rand.New(rand.NewSource(1))
+	deadlines := make(map[string]uint64)
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		deadline := uint64(r.Int63n(1 << (wheelBits * (1 + r.Intn(wheelLevels)))))
+		deadlines[key] = max(deadline, 1)
+		wheel.add(key, deadline)
+	}
+	// Beyond the range of the wheel.
+	for i := 0; i < 10; i++ {
+		key := fmt.Sprintf("far-%d", i)
+		deadlines[key] = 1<<(wheelBits*wheelLevels) + uint64(r.Intn(1<<16))
+		wheel.add(key, deadlines[key])
+	}
+
+	fired := 0
+	for tick := uint64(1); fired < len(deadlines); tick += uint64(1 + r.Intn(1000)) {
+		previous := wheel.current
+		wheel.advance(tick, func(key string, deadline uint64) {
+			if deadline != deadlines[key] || deadline <= previous || deadline > tick {
+				t.Fatalf("timer of %s due at tick %d fired while advancing from %d to %d", key, deadline, previous, tick)
+			}
+			fired++
+		})
+	}
+	if wheel.count != 0 {
+		t.Errorf("%d timers left in the wheel, expected: 0", wheel.count)
+	}
+}
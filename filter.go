@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+
+	"golookup/sketch"
+)
+
+// Bits per key used by EnableFilter when none is given. Ten bits per key
+// give a false positive rate of about 1%.
+const defaultFilterBitsPerKey int = 10
+
+// bloomFilter is the Bloom filter of a HashTable. It is fed the FNV-1a
+// hashes the table already computed, so no key is hashed twice.
+type bloomFilter struct {
+	bloom      *sketch.Bloom
+	bitsPerKey int
+}
+
+// newBloomFilter returns a filter for the keys a table of the given length
+// holds before it grows.
+func newBloomFilter(length uint64, bitsPerKey int) *bloomFilter {
+	capacity := uint64(float32(length)*risizeUpThreshold) + 1
+	size := uint64(64)
+	for size < capacity*uint64(bitsPerKey) {
+		size *= 2
+	}
+	// k = ln 2 * bits per key minimizes the false positive rate.
+	hashes := uint64(math.Round(math.Ln2 * float64(bitsPerKey)))
+	bloom, err := sketch.NewBloomBits(size, min(max(hashes, 1), 16), sketch.DefaultHasher)
+	if err != nil {
+		// The size and hashes are positive and the default hasher exists.
+		panic(err)
+	}
+	return &bloomFilter{bloom: bloom, bitsPerKey: bitsPerKey}
+}
+
+func (f *bloomFilter) add(hash uint64) {
+	f.bloom.AddHash(hash)
+}
+
+// mayContain reports false if the key with the given hash is certainly not
+// in the table.
+func (f *bloomFilter) mayContain(hash uint64) bool {
+	return f.bloom.ContainsHash(hash)
+}
+
+// EnableFilter puts a Bloom filter with bitsPerKey bits per key in front of
+// Search and Delete, so most lookups of missing keys return without probing.
+// A bitsPerKey of 0 selects the default of 10. The filter is sized from the
+// table length and rebuilt on every resize; deleted keys stay in it until
+// then.
+func (h *HashTable[V]) EnableFilter(bitsPerKey int) {
+	if bitsPerKey <= 0 {
+		bitsPerKey = defaultFilterBitsPerKey
+	}
+	h.filter = newBloomFilter(h.length, bitsPerKey)
+	for i := range h.slots {
+		if h.slots[i].state == slotOccupied {
+			h.filter.add(h.slots[i].key.hash)
+		}
+	}
+}
+
+// DisableFilter removes the Bloom filter.
+func (h *HashTable[V]) DisableFilter() {
+	h.filter = nil
+}
+
+// filtered reports whether the filter rules out k, counting the outcome.
+func (h *HashTable[V]) filtered(k nodeKey) bool {
+	if h.filter == nil {
+		return false
+	}
+	if !h.filter.mayContain(k.hash) {
+		if h.lookupStats {
+			h.stats.filterRejects++
+		}
+		return true
+	}
+	if h.lookupStats {
+		h.stats.filterPasses++
+	}
+	return false
+}
+
+// filterMissed counts a key the filter let through but the table did not hold.
+func (h *HashTable[V]) filterMissed() {
+	if h.filter != nil && h.lookupStats {
+		h.stats.filterFalsePositives++
+	}
+}
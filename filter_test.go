@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFilterRejectsMissingKeys(t *testing.T) {
+	table := buildHashTable(makeSequentialKeys(10000), 10)
+	table.EnableFilter(0)
+	table.EnableLookupStats()
+	if err := table.Validate(); err != nil {
+		t.Fatalf("Validate() after EnableFilter: %v", err)
+	}
+
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Fatalf("Search(%s) = %d, %v, expected: %d", key, value, err, i)
+		}
+	}
+	for i := 0; i < 100000; i++ {
+		if _, err := table.Search(fmt.Sprintf("missing-%d", i)); err == nil {
+			t.Fatalf("Search(missing-%d) found a missing key", i)
+		}
+	}
+
+	stats := table.Stats().Filter
+	if stats == nil {
+		t.Fatalf("Stats().Filter = nil, expected filter stats")
+	}
+	if stats.Rejects+stats.FalsePositives != 100000 || stats.Passes != 10000+stats.FalsePositives {
+		t.Errorf("Rejects = %d, Passes = %d, FalsePositives = %d, expected them to add up to the lookups", stats.Rejects, stats.Passes, stats.FalsePositives)
+	}
+	// The filter is sized for the keys a table holds before it grows, so it
+	// is at most 60% full and does better than the nominal 1%.
+	if stats.FalsePositiveRate > 0.02 {
+		t.Errorf("FalsePositiveRate = %.4f, expected at most 0.02", stats.FalsePositiveRate)
+	}
+}
+
+func TestFilterFollowsInsertsAndResizes(t *testing.T) {
+	table := New[int](10)
+	table.EnableFilter(8)
+	keys := makeSequentialKeys(5000)
+	for i, key := range keys {
+		table.Insert(key, i)
+	}
+	if table.Stats().Grows == 0 {
+		t.Fatalf("expected the table to grow")
+	}
+	for i, key := range keys {
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Fatalf("Search(%s) = %d, %v after resizes, expected: %d", key, value, err, i)
+		}
+	}
+
+	for _, key := range keys[:4500] {
+		if err := table.Delete(key); err != nil {
+			t.Fatalf("Delete(%s): %v", key, err)
+		}
+	}
+	if err := table.Delete(keys[0]); err == nil {
+		t.Errorf("Delete(%s) twice succeeded, expected an error", keys[0])
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after deletes: %v", err)
+	}
+
+	// Deleted keys stay in the filter until a resize rebuilds it.
+	before := table.filter.bloom.FillRatio()
+	table.resize(table.length)
+	if after := table.filter.bloom.FillRatio(); after > before/5 {
+		t.Errorf("FillRatio() = %.3f after resize, %.3f before, expected the deleted keys to be dropped", after, before)
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after resize: %v", err)
+	}
+}
+
+func TestFilterUpsertAndLookup(t *testing.T) {
+	table := New[int](10)
+	table.EnableFilter(0)
+	*table.upsert("counted") += 3
+	if value := table.lookup("counted"); value == nil || *value != 3 {
+		t.Errorf("lookup(counted) = %v, expected: 3", value)
+	}
+	if table.lookup("missing") != nil {
+		t.Errorf("lookup(missing) != nil, expected nil")
+	}
+}
+
+func TestFilterGobDecodeKeepsFilter(t *testing.T) {
+	source := buildHashTable(makeSequentialKeys(100), 10)
+	encoded, err := source.GobEncode()
+	if err != nil {
+		t.Fatalf("GobEncode(): %v", err)
+	}
+	table := New[int](10)
+	table.EnableFilter(12)
+	if err := table.GobDecode(encoded); err != nil {
+		t.Fatalf("GobDecode(): %v", err)
+	}
+	if table.filter == nil || table.filter.bitsPerKey != 12 {
+		t.Fatalf("filter = %+v after GobDecode, expected the 12 bits per key filter to be kept", table.filter)
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after GobDecode: %v", err)
+	}
+}
+
+func TestDisableFilter(t *testing.T) {
+	table := buildHashTable(makeSequentialKeys(100), 10)
+	table.EnableFilter(0)
+	table.DisableFilter()
+	table.Search("missing")
+	if table.Stats().Filter != nil {
+		t.Errorf("Stats().Filter != nil after DisableFilter")
+	}
+}
+
+// benchmarkMissHeavySearch searches a table of a million keys with nine
+// missing keys for every existing one.
+func benchmarkMissHeavySearch(b *testing.B, bitsPerKey int) {
+	totalItems := 1_000_000
+	table := buildHashTable(makeSequentialKeys(totalItems), 2_000_000)
+	if bitsPerKey > 0 {
+		table.EnableFilter(bitsPerKey)
+	}
+	lookups := make([]string, 1024)
+	for i := range lookups {
+		if i%10 == 0 {
+			lookups[i] = fmt.Sprintf("key-%d", i*977)
+		} else {
+			lookups[i] = fmt.Sprintf("missing-%d", i)
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Search(lookups[i%len(lookups)])
+	}
+}
+
+func BenchmarkMissHeavySearch(b *testing.B) {
+	benchmarkMissHeavySearch(b, 0)
+}
+
+func BenchmarkFilteredMissHeavySearch(b *testing.B) {
+	benchmarkMissHeavySearch(b, defaultFilterBitsPerKey)
+}
+
+func BenchmarkFilteredSearchNonExistingKey(b *testing.B) {
+	totalItems := 1_000_000
+	table := buildHashTable(makeSequentialKeys(totalItems), 2_000_000)
+	table.EnableFilter(0)
+	missingKey := "key-missing"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		value, err := table.Search(missingKey)
+		if err == nil || value != 0 {
+			b.Fatalf(`Search(%s) expected not found, got value=%v error=%v`, missingKey, value, err)
+		}
+	}
+}
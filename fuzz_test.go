@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+const (
+	fuzzOpInsert byte = iota
+	fuzzOpSearch
+	fuzzOpDelete
+	fuzzOpBurst
+	fuzzOpIterate
+	fuzzOpCount
+)
+
+// fuzzKeyPool holds keys that collide on the home slot of small tables, so
+// that the fuzzer exercises long probe chains and tombstone reuse.
+var fuzzKeyPool = func() []string {
+	var pool []string
+	for _, tableLength := range []uint64{17, 23, 37} {
+		pool = append(pool, findCollidingKeys(8, tableLength)...)
+	}
+	return append(pool, makeSequentialKeys(16)...)
+}()
+
+// fuzzInput decodes operations from the fuzzer byte stream. Reading past the
+// end yields zeros, so every input is a valid sequence of operations.
+type fuzzInput struct {
+	data []byte
+}
+
+func (in *fuzzInput) done() bool {
+	return len(in.data) == 0
+}
+
+func (in *fuzzInput) byte() byte {
+	if len(in.data) == 0 {
+		return 0
+	}
+	b := in.data[0]
+	in.data = in.data[1:]
+	return b
+}
+
+// key returns either a key of the pool or, for selector bytes with the high
+// bit set, a literal key read from the stream.
+func (in *fuzzInput) key() string {
+	selector := in.byte()
+	if selector < 0x80 {
+		return fuzzKeyPool[int(selector)%len(fuzzKeyPool)]
+	}
+	length := int(selector) % (maxKeyLength + 1)
+	if length > len(in.data) {
+		length = len(in.data)
+	}
+	key := string(in.data[:length])
+	in.data = in.data[length:]
+	return key
+}
+
+// compareWithMap checks that table holds exactly the entries of reference:
+// every reference key is found with its value and All yields no other key.
+func compareWithMap(t *testing.T, step int, table *HashTable[int], reference map[string]int) {
+	t.Helper()
+	if table.Len() != len(reference) {
+		t.Fatalf("step %d: Len() = %d, expected: %d", step, table.Len(), len(reference))
+	}
+	for key, expected := range reference {
+		if value, err := table.Search(key); err != nil || value != expected {
+			t.Fatalf("step %d: Search(%q) = %d, %v, expected: %d", step, key, value, err, expected)
+		}
+	}
+	for key := range table.All() {
+		if _, ok := reference[key]; !ok {
+			t.Fatalf("step %d: All() yielded %q, which is not in the reference map", step, key)
+		}
+	}
+	if err := table.Validate(); err != nil {
+		t.Fatalf("step %d: Validate returned error: %v", step, err)
+	}
+}
+
+func FuzzHashTableAgainstMap(f *testing.F) {
+	f.Add([]byte{fuzzOpInsert, 0, fuzzOpSearch, 0, fuzzOpDelete, 0, fuzzOpSearch, 0})
+	// Fill a collision chain, delete from its middle and search past the tombstone.
+	f.Add([]byte{
+		fuzzOpInsert, 0, fuzzOpInsert, 1, fuzzOpInsert, 2, fuzzOpInsert, 3,
+		fuzzOpDelete, 1, fuzzOpSearch, 2, fuzzOpSearch, 3, fuzzOpInsert, 1,
+		fuzzOpIterate,
+	})
+	// Grow with a burst, then shrink by deleting everything.
+	f.Add([]byte{fuzzOpBurst, 60, fuzzOpIterate, fuzzOpBurst, 200, fuzzOpIterate})
+	f.Add([]byte{fuzzOpInsert, 0x85, 'a', 'b', 'c', 'd', 'e', fuzzOpSearch, 0x85, 'a', 'b', 'c', 'd', 'e'})
+	f.Add([]byte{fuzzOpInsert, 0x80, fuzzOpSearch, 0x80, fuzzOpDelete, 0x80})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		table := New[int](0)
+		reference := make(map[string]int)
+		in := &fuzzInput{data: data}
+
+		for step := 0; !in.done(); step++ {
+			switch in.byte() % fuzzOpCount {
+			case fuzzOpInsert:
+				key := in.key()
+				table.Insert(key, step)
+				reference[key] = step
+
+			case fuzzOpSearch:
+				key := in.key()
+				value, err := table.Search(key)
+				expected, ok := reference[key]
+				if ok != (err == nil) || value != expected {
+					t.Fatalf("step %d: Search(%q) = %d, %v, expected: %d, found: %v", step, key, value, err, expected, ok)
+				}
+
+			case fuzzOpDelete:
+				key := in.key()
+				err := table.Delete(key)
+				_, ok := reference[key]
+				if ok != (err == nil) {
+					t.Fatalf("step %d: Delete(%q) = %v, expected key found: %v", step, key, err, ok)
+				}
+				delete(reference, key)
+
+			case fuzzOpBurst:
+				// Inserting many distinct keys forces resizes up, deleting them
+				// again forces resizes down once the tombstones are gone.
+				count := int(in.byte())
+				for i := 0; i < count; i++ {
+					key := fmt.Sprintf("burst-%d-%d", step, i)
+					table.Insert(key, i)
+					reference[key] = i
+				}
+				if in.byte()%2 == 0 {
+					for i := 0; i < count; i++ {
+						key := fmt.Sprintf("burst-%d-%d", step, i)
+						if err := table.Delete(key); err != nil {
+							t.Fatalf("step %d: Delete(%q) returned error: %v", step, key, err)
+						}
+						delete(reference, key)
+					}
+				}
+
+			case fuzzOpIterate:
+				seen := make(map[string]bool, len(reference))
+				for key, value := range table.All() {
+					expected, ok := reference[key]
+					if !ok || value != expected {
+						t.Fatalf("step %d: All() yielded %q = %d, expected: %d, found: %v", step, key, value, expected, ok)
+					}
+					if seen[key] {
+						t.Fatalf("step %d: All() yielded %q twice", step, key)
+					}
+					seen[key] = true
+				}
+				if len(seen) != len(reference) {
+					t.Fatalf("step %d: All() yielded %d keys, expected: %d", step, len(seen), len(reference))
+				}
+			}
+			compareWithMap(t, step, table, reference)
+		}
+	})
+}
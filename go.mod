@@ -1,5 +1,5 @@
 module golookup
 
-go 1.22.3
+go 1.23
 
 require github.com/beevik/guid v1.0.0 // indirect
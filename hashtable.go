@@ -4,13 +4,18 @@ import (
 	"errors"
 
 	"math"
+	"time"
 	// "fmt"
 	"hash/fnv"
+	"iter"
+
+	"golookup/sketch"
 )
 
 const risizeUpThreshold float32 = 0.60
 const resizeDownThreshold float32 = 0.12
 const keyNotFoundErrorMsg string = "key not found"
+const maxKeyLength int = 36
 
 var primes = []uint64{
 	17,
@@ -44,7 +49,6 @@ var primes = []uint64{
 	1610612741,
 }
 
-const fnvPrime uint64 = 1099511628211
 const maxUint64 uint64 = 18446744073709551615
 
 type nodeKey struct {
@@ -54,7 +58,7 @@ type nodeKey struct {
 
 func NewKey(key string) nodeKey {
 
-	if len(key) > 36 {
+	if len(key) > maxKeyLength {
 		panic("A Key can't be longer than 36 characters!")
 	}
 
@@ -118,7 +122,7 @@ func isPrime(candidate uint64) bool {
 }
 
 func computePrimeNumber(candidate uint64) uint64 {
-	var start uint64 = 0
+	start := candidate
 
 	if candidate%2 == 0 {
 		start = candidate + uint64(1)
@@ -159,17 +163,10 @@ func getPrime(candidate uint64, nextSizeUp bool) uint64 {
 
 }
 
-// Custom implementation of the FNV-1a hashing algorithm
+// Custom implementation of the FNV-1a hashing algorithm, shared with the
+// sketch package so its filters and estimators hash keys the same way.
 func fnvHash(key string) uint64 {
-	var hash uint64 = 14695981039346656037
-	len := len(key)
-	for i := 0; i < len; i++ {
-		hash ^= uint64(key[i])
-		hash *= fnvPrime
-
-	}
-
-	return hash
+	return sketch.FNV1a(key)
 }
 
 func fnvHashLib(key string) uint64 {
@@ -180,49 +177,78 @@ func fnvHashLib(key string) uint64 {
 }
 
 type HashTable[V any] struct {
-	length               uint64
-	slots                []data[V]
-	activeSlotCounter    uint64
-	occupiedSlotCounter  uint64
-	debugCollistionCount uint64
+	length              uint64
+	slots               []data[V]
+	activeSlotCounter   uint64
+	occupiedSlotCounter uint64
+	stats               tableStats
+	// lookupStats is set by EnableLookupStats.
+	lookupStats bool
+	// filter is the optional Bloom filter set up by EnableFilter.
+	filter *bloomFilter
 }
 
 func New[V any](length uint64) *HashTable[V] {
 
 	primeLength := pickLargestLength(length)
 	return &HashTable[V]{
-		length:               primeLength,
-		slots:                make([]data[V], primeLength),
-		activeSlotCounter:    0,
-		occupiedSlotCounter:  0,
-		debugCollistionCount: 0,
+		length:              primeLength,
+		slots:               make([]data[V], primeLength),
+		activeSlotCounter:   0,
+		occupiedSlotCounter: 0,
 	}
 }
 
-func (h *HashTable[V]) computeNextSizeDown() uint64 {
+func nextSizeDown(length uint64) uint64 {
 
-	candidate := h.length / 2
+	candidate := length / 2
+	// Tables never shrink below the smallest pre-computed prime, which is
+	// also the smallest length New creates.
+	if candidate < primes[0] {
+		return primes[0]
+	}
 	return getPrime(candidate, false)
 }
 
-func (h *HashTable[V]) computeNextSizeUp() uint64 {
-	if h.length*2 >= maxUint64 {
+func nextSizeUp(length uint64) uint64 {
+	if length*2 >= maxUint64 {
 		panic("The hash table cant be resized again because it will overflow uint64!")
 	}
-	candidate := h.length * 2
+	candidate := length * 2
 
 	return getPrime(candidate, true)
 }
 
-func (h *HashTable[V]) doubleHashing(key nodeKey, collisionCount uint64) uint64 {
-	hashKey := key.hash
-	hash1 := hashKey % h.length
-	hash2 := 1 + (hashKey % (h.length - 1))
+func (h *HashTable[V]) computeNextSizeDown() uint64 {
+	return nextSizeDown(h.length)
+}
+
+func (h *HashTable[V]) computeNextSizeUp() uint64 {
+	return nextSizeUp(h.length)
+}
+
+// probeIndex returns the slot visited after collisionCount collisions by a key
+// with the given hash, in a table of the given length.
+func probeIndex(hashKey uint64, length uint64, collisionCount uint64) uint64 {
+	hash1 := hashKey % length
+	hash2 := 1 + (hashKey % (length - 1))
 
 	//floatCollisionCount := float64(collisionCount)
 	//tetrahedralFloat := (math.Pow(floatCollisionCount, 3) - floatCollisionCount) / 6
-	//return ((hash1 + collisionCount*hash2) + uint64(tetrahedralFloat)) % h.length
-	return (hash1 + collisionCount*hash2) % h.length
+	//return ((hash1 + collisionCount*hash2) + uint64(tetrahedralFloat)) % length
+	return (hash1 + collisionCount*hash2) % length
+}
+
+func (h *HashTable[V]) slotState(index uint64) uint8 {
+	return h.slots[index].state
+}
+
+func (h *HashTable[V]) slotMatches(index uint64, key nodeKey) bool {
+	return h.slots[index].key.value == key.value
+}
+
+func (h *HashTable[V]) doubleHashing(key nodeKey, collisionCount uint64) uint64 {
+	return probeIndex(key.hash, h.length, collisionCount)
 }
 
 func (h *HashTable[V]) computeLoadFactor() float32 {
@@ -231,11 +257,21 @@ func (h *HashTable[V]) computeLoadFactor() float32 {
 }
 
 func (h *HashTable[V]) resize(newSize uint64) {
+	start := time.Now()
+	if newSize > h.length {
+		h.stats.grows++
+	} else {
+		h.stats.shrinks++
+	}
 
 	h.length = newSize
 	h.activeSlotCounter = 0
 	h.occupiedSlotCounter = 0
 	newSlots := make([]data[V], newSize)
+	// The filter is rebuilt for the new length and loses deleted keys.
+	if h.filter != nil {
+		h.filter = newBloomFilter(newSize, h.filter.bitsPerKey)
+	}
 
 	for i := range len(h.slots) {
 		item := h.slots[i]
@@ -247,7 +283,7 @@ func (h *HashTable[V]) resize(newSize uint64) {
 		h.insert(newSlots, item.key, item.value)
 	}
 	h.slots = newSlots
-
+	h.stats.resizeTime += time.Since(start)
 }
 func (h *HashTable[V]) insertItem(slots []data[V], index uint64, key nodeKey, value V) {
 	wasEmpty := slots[index].state == slotEmpty
@@ -260,6 +296,9 @@ func (h *HashTable[V]) insertItem(slots []data[V], index uint64, key nodeKey, va
 	if wasEmpty {
 		h.occupiedSlotCounter++
 	}
+	if h.filter != nil {
+		h.filter.add(key.hash)
+	}
 }
 
 func (*HashTable[V]) updateValue(slots []data[V], index uint64, key nodeKey, value V) bool {
@@ -270,7 +309,9 @@ func (*HashTable[V]) updateValue(slots []data[V], index uint64, key nodeKey, val
 	return false
 }
 
-func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
+// insert places the key-value pair into slots and returns the number of
+// collisions encountered while probing.
+func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) uint64 {
 
 	var collisionCount uint64 = 0
 	homeLocation := h.doubleHashing(key, collisionCount)
@@ -279,7 +320,7 @@ func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
 
 	if slots[homeLocation].state == slotEmpty {
 		h.insertItem(slots, homeLocation, key, value)
-		return
+		return collisionCount
 	}
 
 	if slots[homeLocation].state == slotTombstone {
@@ -288,13 +329,12 @@ func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
 	}
 
 	if h.updateValue(slots, homeLocation, key, value) {
-		return
+		return collisionCount
 	}
 
 	// Start Probing
 	for {
 		collisionCount++
-		h.debugCollistionCount++
 		deltaLocation := h.doubleHashing(key, collisionCount)
 
 		if deltaLocation == homeLocation {
@@ -302,7 +342,7 @@ func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
 		}
 
 		if h.updateValue(slots, deltaLocation, key, value) {
-			return
+			return collisionCount
 		}
 
 		// If a tombstone is found during probing, it can be marked as the first tombstone found.
@@ -319,10 +359,10 @@ func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
 		if slots[deltaLocation].state == slotEmpty {
 			if hasTombstone {
 				h.insertItem(slots, firstTombstone, key, value)
-				return
+				return collisionCount
 			}
 			h.insertItem(slots, deltaLocation, key, value)
-			return
+			return collisionCount
 		}
 	}
 
@@ -331,7 +371,7 @@ func (h *HashTable[V]) insert(slots []data[V], key nodeKey, value V) {
 	if hasTombstone {
 		h.insertItem(slots, firstTombstone, key, value)
 	}
-
+	return collisionCount
 }
 
 func (h *HashTable[V]) Insert(key string, value V) {
@@ -344,21 +384,101 @@ func (h *HashTable[V]) Insert(key string, value V) {
 	}
 	k := NewKey(key)
 
-	h.insert(h.slots, k, value)
+	collisionCount := h.insert(h.slots, k, value)
+	h.stats.inserts.record(collisionCount)
+}
+
+// Len returns the number of keys stored in the table.
+func (h *HashTable[V]) Len() int {
+	return int(h.activeSlotCounter)
 }
 
+// All returns an iterator over the key-value pairs of the table in slot order,
+// which depends on the key hashes and changes on every resize. The table must
+// not be modified while iterating.
+func (h *HashTable[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for i := range h.slots {
+			item := &h.slots[i]
+			if item.state != slotOccupied {
+				continue
+			}
+			if !yield(item.key.value, item.value) {
+				return
+			}
+		}
+	}
+}
+
+// Search returns the value of key. Unless EnableLookupStats was called it
+// doesn't write to the table, so concurrent Searches are safe as long as
+// nothing modifies the table.
 func (h *HashTable[V]) Search(key string) (V, error) {
-	var collisionCount uint64 = 0
-	var zero V
+	return h.search(NewKey(key))
+}
 
+// lookup returns a pointer to the value of key inside its slot, or nil if key
+// is not in the table. The pointer is only valid until the next Insert or
+// Delete, which may resize the table.
+func (h *HashTable[V]) lookup(key string) *V {
 	k := NewKey(key)
+	if h.filtered(k) {
+		return nil
+	}
+	location, collisionCount, found := probeSlotsCounted(h, h.length, k)
+	h.recordLookup(collisionCount)
+	if !found {
+		h.filterMissed()
+		return nil
+	}
+	return &h.slots[location].value
+}
+
+// upsert returns a pointer to the value of key inside its slot, inserting
+// key with the zero value first if it is not in the table. It probes the
+// table once either way. The pointer is only valid until the next Insert or
+// Delete.
+func (h *HashTable[V]) upsert(key string) *V {
+	if h.computeLoadFactor() >= risizeUpThreshold {
+		h.resize(h.computeNextSizeUp())
+	}
+	k := NewKey(key)
+	// Like insert, a new key goes to the first tombstone of the probe
+	// sequence. The load factor check above guarantees a free slot.
+	location, collisionCount, found := probeSlotsCounted(h, h.length, k)
+	h.stats.inserts.record(collisionCount)
+	if !found {
+		var zero V
+		h.insertItem(h.slots, location, k, zero)
+	}
+	return &h.slots[location].value
+}
+
+func (h *HashTable[V]) search(k nodeKey) (V, error) {
+	if h.filtered(k) {
+		var zero V
+		return zero, errors.New(keyNotFoundErrorMsg)
+	}
+	value, err := h.probe(k)
+	if err != nil {
+		h.filterMissed()
+	}
+	return value, err
+}
+
+// probe looks k up in the slots.
+func (h *HashTable[V]) probe(k nodeKey) (V, error) {
+	var collisionCount uint64 = 0
+	var zero V
 
 	homeLocation := h.doubleHashing(k, collisionCount)
 	item := h.slots[homeLocation]
 	if item.state == slotEmpty {
+		h.recordLookup(collisionCount)
 		return zero, errors.New(keyNotFoundErrorMsg)
 	}
-	if item.state == slotOccupied && item.key.value == key {
+	if item.state == slotOccupied && item.key.value == k.value {
+		h.recordLookup(collisionCount)
 		return item.value, nil
 	}
 
@@ -371,14 +491,17 @@ func (h *HashTable[V]) Search(key string) (V, error) {
 		}
 		item := h.slots[deltaLocation]
 		if item.state == slotEmpty {
+			h.recordLookup(collisionCount)
 			return zero, errors.New(keyNotFoundErrorMsg)
 		}
 
-		if item.state == slotOccupied && item.key.value == key {
+		if item.state == slotOccupied && item.key.value == k.value {
+			h.recordLookup(collisionCount)
 			return item.value, nil
 		}
 	}
 
+	h.recordLookup(collisionCount)
 	return zero, errors.New(keyNotFoundErrorMsg)
 }
 
@@ -387,28 +510,49 @@ func (h *HashTable[V]) deleteItem(item *data[V]) {
 	item.value = zero
 	item.state = slotTombstone
 	h.activeSlotCounter--
+	h.stats.deletes++
+}
+
+// shrink resizes the table down one step once it has become sparse.
+func (h *HashTable[V]) shrink() {
 	loadFactor := h.computeLoadFactor()
-	if loadFactor <= resizeDownThreshold {
+	if loadFactor <= resizeDownThreshold && h.length > primes[0] {
 		newLength := h.computeNextSizeDown()
 		h.resize(newLength)
 	}
 }
 
 func (h *HashTable[V]) Delete(key string) error {
+	if err := h.delete(NewKey(key)); err != nil {
+		return err
+	}
+	h.shrink()
+	return nil
+}
 
-	// TODO: Test deletion when probing
-
-	k := NewKey(key)
+// delete marks the slot of k as a tombstone without resizing the table.
+func (h *HashTable[V]) delete(k nodeKey) error {
+	if h.filtered(k) {
+		return errors.New(keyNotFoundErrorMsg)
+	}
+	err := h.deleteKey(k)
+	if err != nil {
+		h.filterMissed()
+	}
+	return err
+}
 
+func (h *HashTable[V]) deleteKey(k nodeKey) error {
 	var collisionCount uint64 = 0
 	homeLocation := h.doubleHashing(k, collisionCount)
 	item := &h.slots[homeLocation]
 
 	if item.state == slotEmpty {
+		h.recordLookup(collisionCount)
 		return errors.New(keyNotFoundErrorMsg)
 	}
-	if item.state == slotOccupied && item.key.value == key {
-
+	if item.state == slotOccupied && item.key.value == k.value {
+		h.recordLookup(collisionCount)
 		h.deleteItem(item)
 		return nil
 	}
@@ -423,12 +567,15 @@ func (h *HashTable[V]) Delete(key string) error {
 		}
 		item := &h.slots[deltaLocation]
 		if item.state == slotEmpty {
+			h.recordLookup(collisionCount)
 			return errors.New(keyNotFoundErrorMsg)
 		}
-		if item.state == slotOccupied && item.key.value == key {
+		if item.state == slotOccupied && item.key.value == k.value {
+			h.recordLookup(collisionCount)
 			h.deleteItem(item)
 			return nil
 		}
 	}
+	h.recordLookup(collisionCount)
 	return errors.New(keyNotFoundErrorMsg)
 }
@@ -175,6 +175,21 @@ func TestResizeDown(t *testing.T) {
 	}
 }
 
+func TestResizeDownStopsAtSmallestPrime(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	hashTable.Insert("foo-2", 2)
+	hashTable.Delete("foo-1")
+	hashTable.Delete("foo-2")
+
+	if hashTable.length != primes[0] {
+		t.Errorf("HashTable length = %d, but expected: %d", hashTable.length, primes[0])
+	}
+	if err := hashTable.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
 func TestUpdateValue(t *testing.T) {
 	var length uint64 = 10
 	hashTable := New[int](length)
@@ -204,6 +219,42 @@ func TestProbingWhenInserting(t *testing.T) {
 	}
 }
 
+func TestDeleteWhenProbing(t *testing.T) {
+	var length uint64 = 389
+	keys := findCollidingKeys(6, length)
+	hashTable := buildHashTable(keys, length)
+
+	// Deleting a key in the middle of the collision chain must leave the keys
+	// probed after it reachable.
+	if err := hashTable.Delete(keys[2]); err != nil {
+		t.Fatalf("Delete(%s) returned error: %v", keys[2], err)
+	}
+	if err := hashTable.Validate(); err != nil {
+		t.Fatalf("Validate after Delete returned error: %v", err)
+	}
+	for i, key := range keys {
+		value, err := hashTable.Search(key)
+		if i == 2 {
+			if err == nil {
+				t.Errorf("Search(%s) = %d, expected deleted key to be missing", key, value)
+			}
+			continue
+		}
+		if err != nil || value != i {
+			t.Errorf("Search(%s) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+
+	// Re-inserting the deleted key reuses its tombstone.
+	hashTable.Insert(keys[2], 200)
+	if err := hashTable.Validate(); err != nil {
+		t.Fatalf("Validate after re-Insert returned error: %v", err)
+	}
+	if hashTable.occupiedSlotCounter != uint64(len(keys)) {
+		t.Errorf("HashTable occupiedSlotCounter = %d, but expected: %d", hashTable.occupiedSlotCounter, len(keys))
+	}
+}
+
 func BenchmarkSearchExistingKey(b *testing.B) {
 	totalItems := 1_000_000
 	keys := makeSequentialKeys(totalItems)
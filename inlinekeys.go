@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+)
+
+// Keys up to this length are stored inline in their slot. Together with the
+// hash, the length byte and the slot state, an inline key fills 32 bytes.
+const inlineKeySize int = 22
+
+// keyArena stores key bytes back to back in a single allocation. Deleted keys
+// are not reclaimed until the arena is rebuilt.
+type keyArena struct {
+	data    []byte
+	garbage uint64
+}
+
+func (a *keyArena) add(key string) uint32 {
+	offset := len(a.data)
+	if uint64(offset)+uint64(len(key)) > uint64(^uint32(0)) {
+		panic("The key arena can't grow beyond 4GiB!")
+	}
+	a.data = append(a.data, key...)
+	return uint32(offset)
+}
+
+func (a *keyArena) get(offset uint32, length uint8) []byte {
+	return a.data[offset : offset+uint32(length)]
+}
+
+// inlineSlot holds a key without a pointer: short keys are stored in
+// keyBytes, longer keys spill into a keyArena and keyBytes holds their arena
+// offset. The fields are ordered so that everything but the value packs into
+// 32 bytes.
+type inlineSlot[V any] struct {
+	hash      uint64
+	keyBytes  [inlineKeySize]byte
+	keyLength uint8
+	state     uint8
+	value     V
+}
+
+func (s *inlineSlot[V]) spilled() bool {
+	return int(s.keyLength) > inlineKeySize
+}
+
+func (s *inlineSlot[V]) arenaOffset() uint32 {
+	return binary.LittleEndian.Uint32(s.keyBytes[:4])
+}
+
+func (s *inlineSlot[V]) setKey(key nodeKey, arena *keyArena) {
+	s.hash = key.hash
+	s.keyLength = uint8(len(key.value))
+	if s.spilled() {
+		binary.LittleEndian.PutUint32(s.keyBytes[:4], arena.add(key.value))
+	} else {
+		copy(s.keyBytes[:], key.value)
+	}
+}
+
+func (s *inlineSlot[V]) keyEquals(key string, arena *keyArena) bool {
+	if int(s.keyLength) != len(key) {
+		return false
+	}
+	if s.spilled() {
+		return string(arena.get(s.arenaOffset(), s.keyLength)) == key
+	}
+	return string(s.keyBytes[:s.keyLength]) == key
+}
+
+func (s *inlineSlot[V]) key(arena *keyArena) string {
+	if s.spilled() {
+		return string(arena.get(s.arenaOffset(), s.keyLength))
+	}
+	return string(s.keyBytes[:s.keyLength])
+}
+
+// InlineKeyHashTable is a HashTable that stores keys without a string header
+// per key. Keys of up to inlineKeySize bytes live inside their slot and longer
+// keys in a shared arena. When V holds no pointers, the whole table consists
+// of two pointer-free allocations the garbage collector doesn't need to scan.
+type InlineKeyHashTable[V any] struct {
+	length              uint64
+	slots               []inlineSlot[V]
+	arena               keyArena
+	activeSlotCounter   uint64
+	occupiedSlotCounter uint64
+}
+
+func NewInlineKey[V any](length uint64) *InlineKeyHashTable[V] {
+	primeLength := pickLargestLength(length)
+	return &InlineKeyHashTable[V]{
+		length: primeLength,
+		slots:  make([]inlineSlot[V], primeLength),
+	}
+}
+
+func (h *InlineKeyHashTable[V]) Len() int {
+	return int(h.activeSlotCounter)
+}
+
+func (h *InlineKeyHashTable[V]) computeLoadFactor() float32 {
+	return float32(h.occupiedSlotCounter) / float32(h.length)
+}
+
+// resize re-inserts the live keys into new slots and a new arena, which drops
+// the arena bytes of deleted keys.
+func (h *InlineKeyHashTable[V]) resize(newSize uint64) {
+	oldSlots, oldArena := h.slots, h.arena
+	h.length = newSize
+	h.slots = make([]inlineSlot[V], newSize)
+	h.arena = keyArena{}
+	h.activeSlotCounter = 0
+	h.occupiedSlotCounter = 0
+
+	for i := range oldSlots {
+		item := &oldSlots[i]
+		if item.state != slotOccupied {
+			continue
+		}
+		key := nodeKey{value: item.key(&oldArena), hash: item.hash}
+		h.insert(key, item.value)
+	}
+}
+
+func (h *InlineKeyHashTable[V]) insertItem(index uint64, key nodeKey, value V) {
+	item := &h.slots[index]
+	if item.state == slotEmpty {
+		h.occupiedSlotCounter++
+	}
+	item.setKey(key, &h.arena)
+	item.state = slotOccupied
+	item.value = value
+	h.activeSlotCounter++
+}
+
+func (h *InlineKeyHashTable[V]) slotState(index uint64) uint8 {
+	return h.slots[index].state
+}
+
+func (h *InlineKeyHashTable[V]) slotMatches(index uint64, key nodeKey) bool {
+	item := &h.slots[index]
+	return item.hash == key.hash && item.keyEquals(key.value, &h.arena)
+}
+
+func (h *InlineKeyHashTable[V]) insert(key nodeKey, value V) {
+	location, found := probeSlots(h, h.length, key)
+	switch {
+	case found:
+		h.slots[location].value = value
+	case location < h.length:
+		h.insertItem(location, key, value)
+	}
+}
+
+func (h *InlineKeyHashTable[V]) Insert(key string, value V) {
+	if h.computeLoadFactor() >= risizeUpThreshold {
+		h.resize(nextSizeUp(h.length))
+	}
+	h.insert(NewKey(key), value)
+}
+
+func (h *InlineKeyHashTable[V]) find(key string) (*inlineSlot[V], bool) {
+	location, found := probeSlots(h, h.length, NewKey(key))
+	if !found {
+		return nil, false
+	}
+	return &h.slots[location], true
+}
+
+func (h *InlineKeyHashTable[V]) Search(key string) (V, error) {
+	item, ok := h.find(key)
+	if !ok {
+		var zero V
+		return zero, errors.New(keyNotFoundErrorMsg)
+	}
+	return item.value, nil
+}
+
+func (h *InlineKeyHashTable[V]) Delete(key string) error {
+	item, ok := h.find(key)
+	if !ok {
+		return errors.New(keyNotFoundErrorMsg)
+	}
+
+	if item.spilled() {
+		h.arena.garbage += uint64(item.keyLength)
+	}
+	var zero V
+	item.value = zero
+	item.state = slotTombstone
+	h.activeSlotCounter--
+
+	if h.computeLoadFactor() <= resizeDownThreshold && h.length > primes[0] {
+		h.resize(nextSizeDown(h.length))
+	}
+	return nil
+}
+
+// containsPointers reports whether values of type t hold any pointer the
+// garbage collector has to scan.
+func containsPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() > 0 && containsPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if containsPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Pointer, reflect.UnsafePointer, reflect.Map, reflect.Chan, reflect.Func,
+		reflect.Interface, reflect.Slice, reflect.String:
+		return true
+	}
+	return false
+}
+
+// InlineKeyStats describes the contents of an InlineKeyHashTable and how much
+// of its key arena is taken by deleted keys.
+type InlineKeyStats struct {
+	Live       uint64
+	Tombstones uint64
+	Capacity   uint64
+	LoadFactor float32
+	// KeyBytes is the size of the key arena, which only holds spilled keys.
+	KeyBytes uint64
+	// KeyGarbage is the number of key arena bytes held by deleted keys. A
+	// resize rebuilds the arena without them.
+	KeyGarbage uint64
+	// Fragmentation is the share of the key arena held by deleted keys.
+	Fragmentation float64
+}
+
+func (h *InlineKeyHashTable[V]) Stats() InlineKeyStats {
+	stats := InlineKeyStats{
+		Live:       h.activeSlotCounter,
+		Tombstones: h.occupiedSlotCounter - h.activeSlotCounter,
+		Capacity:   h.length,
+		LoadFactor: h.computeLoadFactor(),
+		KeyBytes:   uint64(len(h.arena.data)),
+		KeyGarbage: h.arena.garbage,
+	}
+	if stats.KeyBytes > 0 {
+		stats.Fragmentation = float64(stats.KeyGarbage) / float64(stats.KeyBytes)
+	}
+	return stats
+}
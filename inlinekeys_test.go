@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestInlineKeyInsertSearchDelete(t *testing.T) {
+	table := NewInlineKey[int](10)
+	shortKey := "foo-1"
+	longKey := strings.Repeat("x", inlineKeySize+1)
+
+	table.Insert(shortKey, 1)
+	table.Insert(longKey, 2)
+	table.Insert(longKey, 3)
+	if value, err := table.Search(shortKey); err != nil || value != 1 {
+		t.Errorf("Search(%s) = %d, want 1, error: %v", shortKey, value, err)
+	}
+	if value, err := table.Search(longKey); err != nil || value != 3 {
+		t.Errorf("Search(%s) = %d, want 3, error: %v", longKey, value, err)
+	}
+	if len(table.arena.data) != len(longKey) {
+		t.Errorf("arena holds %d bytes, expected only the spilled key: %d", len(table.arena.data), len(longKey))
+	}
+	if table.Len() != 2 {
+		t.Errorf("Len() = %d, expected: 2", table.Len())
+	}
+
+	if err := table.Delete(longKey); err != nil {
+		t.Errorf("Delete(%s) returned error: %v", longKey, err)
+	}
+	if _, err := table.Search(longKey); err == nil {
+		t.Errorf("Search(%s) after Delete = nil error, expected key not found", longKey)
+	}
+	if table.arena.garbage != uint64(len(longKey)) {
+		t.Errorf("arena garbage = %d, expected: %d", table.arena.garbage, len(longKey))
+	}
+}
+
+func TestInlineKeyBoundaryLengths(t *testing.T) {
+	table := NewInlineKey[int](10)
+	var keys []string
+	for length := 0; length <= maxKeyLength; length++ {
+		keys = append(keys, strings.Repeat("k", length))
+	}
+	for i, key := range keys {
+		table.Insert(key, i)
+	}
+	for i, key := range keys {
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Errorf("Search(%q) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+}
+
+func TestInlineKeyResizeRebuildsArena(t *testing.T) {
+	table := NewInlineKey[int](10)
+	for i := 0; i < 100; i++ {
+		table.Insert(fmt.Sprintf("a-long-key-that-spills-%d", i), i)
+	}
+	for i := 0; i < 90; i++ {
+		table.Delete(fmt.Sprintf("a-long-key-that-spills-%d", i))
+	}
+	before := table.Stats()
+	if before.KeyGarbage != before.KeyBytes-10*uint64(len("a-long-key-that-spills-90")) || before.Fragmentation < 0.85 {
+		t.Fatalf("Stats() = %+v, expected the deleted keys to be counted as garbage", before)
+	}
+	table.resize(table.length)
+	after := table.Stats()
+	if after.KeyGarbage != 0 || after.Fragmentation != 0 || after.KeyBytes != 10*uint64(len("a-long-key-that-spills-90")) {
+		t.Errorf("Stats() = %+v after resize, expected the arena to be rebuilt", after)
+	}
+	for i := 90; i < 100; i++ {
+		key := fmt.Sprintf("a-long-key-that-spills-%d", i)
+		if value, err := table.Search(key); err != nil || value != i {
+			t.Errorf("Search(%s) = %d, want %d, error: %v", key, value, i, err)
+		}
+	}
+}
+
+func TestInlineSlotLayout(t *testing.T) {
+	skipUnless64Bit(t)
+	if size := unsafe.Sizeof(inlineSlot[int]{}); size != 40 {
+		t.Errorf("inlineSlot[int] size = %d, expected: 40", size)
+	}
+	if containsPointers(reflect.TypeOf(inlineSlot[int]{})) {
+		t.Errorf("inlineSlot[int] contains pointers, expected a pointer-free slot")
+	}
+	if !containsPointers(reflect.TypeOf(inlineSlot[string]{})) {
+		t.Errorf("inlineSlot[string] contains no pointers, expected the value to hold one")
+	}
+}
+
+// benchmarkGC reports how long a full garbage collection takes while the
+// table built by build is alive.
+func benchmarkGC(b *testing.B, build func(keys []string) any) {
+	keys := makeSequentialKeys(1_000_000)
+	table := build(keys)
+	runtime.GC()
+
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	b.ReportMetric(float64(time.Since(start).Microseconds())/float64(b.N), "us/gc")
+	runtime.KeepAlive(table)
+}
+
+func BenchmarkGCWithHashTable(b *testing.B) {
+	benchmarkGC(b, func(keys []string) any {
+		table := New[int](uint64(len(keys)))
+		for i, key := range keys {
+			table.Insert(key, i)
+		}
+		return table
+	})
+}
+
+func BenchmarkGCWithInlineKeyHashTable(b *testing.B) {
+	benchmarkGC(b, func(keys []string) any {
+		table := NewInlineKey[int](uint64(len(keys)))
+		for i, key := range keys {
+			table.Insert(key, i)
+		}
+		return table
+	})
+}
+
+func BenchmarkInlineKeySearchExistingKey(b *testing.B) {
+	totalItems := 1_000_000
+	keys := makeSequentialKeys(totalItems)
+	targetKey := keys[totalItems/2]
+	table := NewInlineKey[int](2_000_000)
+	for i, key := range keys {
+		table.Insert(key, i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		value, err := table.Search(targetKey)
+		if err != nil || value != totalItems/2 {
+			b.Fatalf(`Search(%s) expected %d, got value=%v error=%v`, targetKey, totalItems/2, value, err)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// EvictionReason tells an OnEvict callback why an entry left the cache.
+type EvictionReason int
+
+const (
+	// EvictCapacity means the entry was the least recently used one when
+	// the cache went over its entry count or byte budget.
+	EvictCapacity EvictionReason = iota
+	// EvictRemoved means the entry was removed by Remove.
+	EvictRemoved
+	// EvictReplaced means Put stored a new value for the key.
+	EvictReplaced
+	// EvictRejected means the cache didn't admit a key added by Put: its
+	// policy turned it down, or it is larger than the MaxBytes of an
+	// LRUCache.
+	EvictRejected
+)
+
+func (r EvictionReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	case EvictRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
+
+type LRUOptions[V any] struct {
+	// MaxEntries is the number of entries kept. Zero means no entry limit.
+	MaxEntries int
+	// MaxBytes is the total size of the entries kept, as reported by
+	// SizeOf. Zero means no byte budget.
+	MaxBytes int64
+	// SizeOf returns the size of an entry. It is required with MaxBytes.
+	SizeOf func(key string, value V) int64
+	// OnEvict, if set, is called for every entry that leaves the cache.
+	OnEvict func(key string, value V, reason EvictionReason)
+}
+
+// CacheStats holds the counters of a cache.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+	// Evictions counts the entries evicted to stay within the budget.
+	Evictions uint64
+}
+
+type lruEntry[V any] struct {
+	value V
+	size  int64
+}
+
+// LRUCache is an OrderedHashTable that keeps its keys from the least to the
+// most recently used and evicts the least recently used keys to stay within
+// an entry count or byte budget. It is not safe for concurrent use, see
+// ConcurrentLRUCache.
+type LRUCache[V any] struct {
+	opts    LRUOptions[V]
+	entries *OrderedHashTable[lruEntry[V]]
+	bytes   int64
+	stats   CacheStats
+}
+
+func validateLRUOptions[V any](opts LRUOptions[V]) error {
+	if opts.MaxEntries < 0 || opts.MaxBytes < 0 {
+		return errors.New("cache limits can't be negative")
+	}
+	if opts.MaxEntries == 0 && opts.MaxBytes == 0 {
+		return errors.New("cache needs MaxEntries or MaxBytes")
+	}
+	if opts.MaxBytes > 0 && opts.SizeOf == nil {
+		return errors.New("cache with MaxBytes needs SizeOf")
+	}
+	return nil
+}
+
+func NewLRU[V any](opts LRUOptions[V]) (*LRUCache[V], error) {
+	if err := validateLRUOptions(opts); err != nil {
+		return nil, err
+	}
+	return &LRUCache[V]{
+		opts:    opts,
+		entries: NewOrdered[lruEntry[V]](lengthFor(opts.MaxEntries)),
+	}, nil
+}
+
+func (c *LRUCache[V]) Len() int {
+	return c.entries.Len()
+}
+
+// Bytes returns the total size of the entries. It is zero without SizeOf.
+func (c *LRUCache[V]) Bytes() int64 {
+	return c.bytes
+}
+
+func (c *LRUCache[V]) Stats() CacheStats {
+	return c.stats
+}
+
+func (c *LRUCache[V]) evicted(key string, entry lruEntry[V], reason EvictionReason) {
+	if c.opts.OnEvict != nil {
+		c.opts.OnEvict(key, entry.value, reason)
+	}
+}
+
+// Get returns the value of key and marks it as the most recently used.
+func (c *LRUCache[V]) Get(key string) (V, bool) {
+	entry, err := c.entries.Search(key)
+	if err != nil {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+	c.stats.Hits++
+	c.entries.MoveToBack(key)
+	return entry.value, true
+}
+
+// Peek returns the value of key without marking it as used or counting a
+// hit or miss.
+func (c *LRUCache[V]) Peek(key string) (V, bool) {
+	entry, err := c.entries.Search(key)
+	return entry.value, err == nil
+}
+
+// Put stores value as the most recently used entry and evicts the least
+// recently used entries while the cache is over its budget. An entry larger
+// than MaxBytes is not stored and is reported to OnEvict as EvictRejected,
+// without counting as an eviction or evicting any other entry; it still
+// replaces a cached value of key.
+func (c *LRUCache[V]) Put(key string, value V) {
+	entry := lruEntry[V]{value: value}
+	if c.opts.SizeOf != nil {
+		entry.size = c.opts.SizeOf(key, value)
+	}
+	if c.opts.MaxBytes > 0 && entry.size > c.opts.MaxBytes {
+		if old, err := c.entries.Search(key); err == nil {
+			c.entries.Delete(key)
+			c.bytes -= old.size
+			c.evicted(key, old, EvictReplaced)
+		}
+		c.evicted(key, entry, EvictRejected)
+		return
+	}
+	if old, err := c.entries.Search(key); err == nil {
+		c.bytes -= old.size
+		c.entries.Insert(key, entry)
+		c.entries.MoveToBack(key)
+		c.evicted(key, old, EvictReplaced)
+	} else {
+		c.entries.Insert(key, entry)
+	}
+	c.bytes += entry.size
+
+	for c.overBudget() {
+		oldest, entry, _ := c.entries.Oldest()
+		c.entries.Delete(oldest)
+		c.bytes -= entry.size
+		c.stats.Evictions++
+		c.evicted(oldest, entry, EvictCapacity)
+	}
+}
+
+func (c *LRUCache[V]) overBudget() bool {
+	if c.opts.MaxEntries > 0 && c.entries.Len() > c.opts.MaxEntries {
+		return true
+	}
+	return c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes
+}
+
+// Remove deletes key from the cache and reports whether it was cached.
+func (c *LRUCache[V]) Remove(key string) bool {
+	entry, err := c.entries.Search(key)
+	if err != nil {
+		return false
+	}
+	c.entries.Delete(key)
+	c.bytes -= entry.size
+	c.evicted(key, entry, EvictRemoved)
+	return true
+}
+
+// eviction is an OnEvict call that ConcurrentLRUCache defers until its lock
+// is released.
+type eviction[V any] struct {
+	key    string
+	value  V
+	reason EvictionReason
+}
+
+// ConcurrentLRUCache is an LRUCache that is safe for concurrent use. Every
+// operation takes a single lock, since Get reorders the keys. OnEvict is
+// called after the lock is released, so it may use the cache.
+type ConcurrentLRUCache[V any] struct {
+	mu      sync.Mutex
+	cache   *LRUCache[V]
+	onEvict func(key string, value V, reason EvictionReason)
+	pending []eviction[V]
+}
+
+func NewConcurrentLRU[V any](opts LRUOptions[V]) (*ConcurrentLRUCache[V], error) {
+	c := &ConcurrentLRUCache[V]{onEvict: opts.OnEvict}
+	if opts.OnEvict != nil {
+		opts.OnEvict = func(key string, value V, reason EvictionReason) {
+			c.pending = append(c.pending, eviction[V]{key, value, reason})
+		}
+	}
+	cache, err := NewLRU(opts)
+	if err != nil {
+		return nil, err
+	}
+	c.cache = cache
+	return c, nil
+}
+
+// unlock releases the lock and then reports the evictions of the operation.
+func (c *ConcurrentLRUCache[V]) unlock() {
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+	for _, e := range pending {
+		c.onEvict(e.key, e.value, e.reason)
+	}
+}
+
+func (c *ConcurrentLRUCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Get(key)
+}
+
+func (c *ConcurrentLRUCache[V]) Peek(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Peek(key)
+}
+
+func (c *ConcurrentLRUCache[V]) Put(key string, value V) {
+	c.mu.Lock()
+	defer c.unlock()
+	c.cache.Put(key, value)
+}
+
+func (c *ConcurrentLRUCache[V]) Remove(key string) bool {
+	c.mu.Lock()
+	defer c.unlock()
+	return c.cache.Remove(key)
+}
+
+func (c *ConcurrentLRUCache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Len()
+}
+
+func (c *ConcurrentLRUCache[V]) Bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Bytes()
+}
+
+func (c *ConcurrentLRUCache[V]) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache.Stats()
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+type evictionRecord struct {
+	key    string
+	value  int
+	reason EvictionReason
+}
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	var evicted []evictionRecord
+	cache, err := NewLRU(LRUOptions[int]{
+		MaxEntries: 2,
+		OnEvict: func(key string, value int, reason EvictionReason) {
+			evicted = append(evicted, evictionRecord{key, value, reason})
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLRU returned error: %v", err)
+	}
+
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Get("a")
+	cache.Put("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("Get(b) found the least recently used key, expected it to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.Get(key); !ok {
+			t.Errorf("Get(%s) = not found, expected the key to be cached", key)
+		}
+	}
+
+	cache.Put("a", 10)
+	cache.Remove("c")
+	expected := []evictionRecord{{"b", 2, EvictCapacity}, {"a", 1, EvictReplaced}, {"c", 3, EvictRemoved}}
+	if !slices.Equal(evicted, expected) {
+		t.Errorf("OnEvict calls = %v, expected: %v", evicted, expected)
+	}
+	if stats := cache.Stats(); stats != (CacheStats{Hits: 3, Misses: 1, Evictions: 1}) {
+		t.Errorf("Stats() = %+v, expected 3 hits, 1 miss and 1 eviction", stats)
+	}
+}
+
+func TestLRUByteBudget(t *testing.T) {
+	cache, err := NewLRU(LRUOptions[string]{
+		MaxBytes: 10,
+		SizeOf:   func(key string, value string) int64 { return int64(len(value)) },
+	})
+	if err != nil {
+		t.Fatalf("NewLRU returned error: %v", err)
+	}
+
+	cache.Put("a", "1234")
+	cache.Put("b", "1234")
+	cache.Put("a", "12")
+	if cache.Bytes() != 6 {
+		t.Errorf("Bytes() = %d, expected: 6", cache.Bytes())
+	}
+	cache.Put("c", "123456")
+	if _, ok := cache.Peek("b"); ok || cache.Bytes() != 8 {
+		t.Errorf("Bytes() = %d, expected b to be evicted to make room for c", cache.Bytes())
+	}
+	cache.Put("d", "12345678901")
+	if cache.Len() != 2 || cache.Bytes() != 8 {
+		t.Errorf("Len() = %d, Bytes() = %d, expected an entry larger than the budget to leave the cache alone", cache.Len(), cache.Bytes())
+	}
+	if _, ok := cache.Peek("d"); ok {
+		t.Errorf("Peek(d) found an entry larger than the budget")
+	}
+	if _, ok := cache.Peek("a"); !ok {
+		t.Errorf("Peek(a) = false, expected a to survive the oversized Put")
+	}
+	if cache.Stats().Evictions != 1 {
+		t.Errorf("Evictions = %d, expected only b to be evicted and d to be rejected", cache.Stats().Evictions)
+	}
+}
+
+func TestLRUOversizedPutReplacesKey(t *testing.T) {
+	var evicted []evictionRecord
+	cache, _ := NewLRU(LRUOptions[int]{
+		MaxBytes: 10,
+		SizeOf:   func(key string, value int) int64 { return int64(value) },
+		OnEvict: func(key string, value int, reason EvictionReason) {
+			evicted = append(evicted, evictionRecord{key, value, reason})
+		},
+	})
+	cache.Put("a", 3)
+	cache.Put("b", 4)
+	cache.Put("a", 11)
+
+	expected := []evictionRecord{{"a", 3, EvictReplaced}, {"a", 11, EvictRejected}}
+	if !slices.Equal(evicted, expected) {
+		t.Errorf("OnEvict calls = %v, expected: %v", evicted, expected)
+	}
+	if _, ok := cache.Peek("b"); !ok || cache.Len() != 1 || cache.Bytes() != 4 {
+		t.Errorf("Len() = %d, Bytes() = %d, expected only b to be cached", cache.Len(), cache.Bytes())
+	}
+	if cache.Stats().Evictions != 0 {
+		t.Errorf("Evictions = %d, expected a rejected entry not to count as an eviction", cache.Stats().Evictions)
+	}
+}
+
+func TestLRUPeekDoesNotReorder(t *testing.T) {
+	cache, _ := NewLRU(LRUOptions[int]{MaxEntries: 2})
+	cache.Put("a", 1)
+	cache.Put("b", 2)
+	cache.Peek("a")
+	cache.Put("c", 3)
+	if _, ok := cache.Peek("a"); ok {
+		t.Errorf("Peek(a) found a, expected Peek not to mark it as used")
+	}
+	if stats := cache.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Stats() = %+v, expected Peek not to be counted", stats)
+	}
+}
+
+func TestNewLRUErrors(t *testing.T) {
+	tests := []LRUOptions[int]{
+		{},
+		{MaxEntries: -1},
+		{MaxBytes: 100},
+	}
+	for _, opts := range tests {
+		if _, err := NewLRU(opts); err == nil {
+			t.Errorf("NewLRU(%+v) = nil error, expected an error", opts)
+		}
+	}
+}
+
+func TestConcurrentLRU(t *testing.T) {
+	var evictions sync.Map
+	var cache *ConcurrentLRUCache[int]
+	cache, err := NewConcurrentLRU(LRUOptions[int]{
+		MaxEntries: 100,
+		OnEvict: func(key string, value int, reason EvictionReason) {
+			// Using the cache from the callback must not deadlock.
+			cache.Len()
+			evictions.Store(key, reason)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewConcurrentLRU returned error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("key-%d-%d", worker, i%200)
+				cache.Put(key, i)
+				cache.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cache.Len() != 100 {
+		t.Errorf("Len() = %d, expected: 100", cache.Len())
+	}
+	stats := cache.Stats()
+	if stats.Hits+stats.Misses != 8000 || stats.Evictions == 0 {
+		t.Errorf("Stats() = %+v, expected 8000 lookups and some evictions", stats)
+	}
+}
+
+func BenchmarkLRUGetPut(b *testing.B) {
+	cache, _ := NewLRU(LRUOptions[int]{MaxEntries: 10_000})
+	keys := makeSequentialKeys(20_000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		key := keys[i%len(keys)]
+		if _, ok := cache.Get(key); !ok {
+			cache.Put(key, i)
+		}
+	}
+}
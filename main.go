@@ -2,31 +2,40 @@ package main
 
 import (
 	"fmt"
-	"unsafe"
+	"os"
 )
 
-type node struct {
-	key   nodeKey
-	value any
-	// isSoftDeleted bool
-}
+const usage string = `usage: golookup <command> [flags]
 
-func main() {
-	fmt.Printf("size: %d bytes", unsafe.Sizeof(node{}))
-}
+commands:
+  dump    build a table from a key file and write a slot visualization
+  bench   run YCSB-style workloads against HashTable and Go's map
+  layout  print field offsets and padding of the slot structs
+  replay  replay a key trace against the cache policies and report hit ratios
+`
 
-// 0-15, 16-32, 33-39
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 
-// 00X00  key
-// 00X01  key
-// ...
-// 00X-15 key
-// 00X-16 value
-// 00X-17 value
-// ....
-// 00X-32 value
-// 00X-33 isSoftDeleted
-// 00X-34 padding (1 byte)
-// 00X-35 padding (1 byte)
-// ...
-// 00X39  padding
+	var err error
+	switch os.Args[1] {
+	case "dump":
+		err = runDump(os.Args[2:])
+	case "bench":
+		err = runBench(os.Args[2:])
+	case "layout":
+		err = runLayout(os.Args[2:])
+	case "replay":
+		err = runReplay(os.Args[2:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
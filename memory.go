@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unsafe"
+)
+
+// MemoryUsage describes the memory held by a HashTable. Memory referenced by
+// the values themselves, such as pointed-to structs or slice backing arrays,
+// is not included.
+type MemoryUsage struct {
+	// Size of a single slot, including padding.
+	SlotSize uint64
+	// Bytes of the slots array.
+	SlotBytes uint64
+	// Bytes of key string data. Tombstones keep their key string alive, so
+	// their keys are included.
+	KeyBytes uint64
+	// SlotBytes + KeyBytes + the HashTable header.
+	TotalBytes uint64
+	// TotalBytes spread over the live entries.
+	BytesPerEntry float64
+	// Bytes per live entry beyond its key data and its value: empty slots,
+	// tombstones, the hash, the state and padding.
+	OverheadPerEntry float64
+}
+
+func (h *HashTable[V]) MemoryUsage() MemoryUsage {
+	slotSize := uint64(unsafe.Sizeof(data[V]{}))
+	var keyBytes, liveKeyBytes uint64
+	for i := range h.slots {
+		item := &h.slots[i]
+		if item.state == slotEmpty {
+			continue
+		}
+		keyBytes += uint64(len(item.key.value))
+		if item.state == slotOccupied {
+			liveKeyBytes += uint64(len(item.key.value))
+		}
+	}
+
+	usage := MemoryUsage{
+		SlotSize:  slotSize,
+		SlotBytes: slotSize * uint64(len(h.slots)),
+		KeyBytes:  keyBytes,
+	}
+	usage.TotalBytes = usage.SlotBytes + usage.KeyBytes + uint64(unsafe.Sizeof(*h))
+	if h.activeSlotCounter > 0 {
+		live := float64(h.activeSlotCounter)
+		var zero V
+		payload := float64(liveKeyBytes) + live*float64(unsafe.Sizeof(zero))
+		usage.BytesPerEntry = float64(usage.TotalBytes) / live
+		usage.OverheadPerEntry = (float64(usage.TotalBytes) - payload) / live
+	}
+	return usage
+}
+
+type fieldLayout struct {
+	Name   string
+	Type   string
+	Offset uintptr
+	Size   uintptr
+	Align  uintptr
+	// Padding inserted after the field to align the next one, or the end of
+	// the struct.
+	Padding uintptr
+}
+
+type structLayout struct {
+	Name   string
+	Size   uintptr
+	Align  uintptr
+	Fields []fieldLayout
+}
+
+func (l structLayout) padding() uintptr {
+	var padding uintptr
+	for _, field := range l.Fields {
+		padding += field.Padding
+	}
+	return padding
+}
+
+func layoutOf(t reflect.Type) structLayout {
+	layout := structLayout{Name: t.String(), Size: t.Size(), Align: uintptr(t.Align())}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		end := t.Size()
+		if i+1 < t.NumField() {
+			end = t.Field(i + 1).Offset
+		}
+		layout.Fields = append(layout.Fields, fieldLayout{
+			Name:    field.Name,
+			Type:    field.Type.String(),
+			Offset:  field.Offset,
+			Size:    field.Type.Size(),
+			Align:   uintptr(field.Type.Align()),
+			Padding: end - field.Offset - field.Type.Size(),
+		})
+	}
+	return layout
+}
+
+// slotLayouts returns the layouts of the structs stored in the slots of a
+// HashTable[V].
+func slotLayouts[V any]() []structLayout {
+	return []structLayout{
+		layoutOf(reflect.TypeOf(data[V]{})),
+		layoutOf(reflect.TypeOf(nodeKey{})),
+	}
+}
+
+type layoutExample struct {
+	ID     uint32
+	Active bool
+	Score  float64
+}
+
+// Value types the layout command can report on.
+var layoutValueTypes = map[string]func() []structLayout{
+	"int":      slotLayouts[int],
+	"int32":    slotLayouts[int32],
+	"int64":    slotLayouts[int64],
+	"uint8":    slotLayouts[uint8],
+	"bool":     slotLayouts[bool],
+	"float64":  slotLayouts[float64],
+	"string":   slotLayouts[string],
+	"[]byte":   slotLayouts[[]byte],
+	"any":      slotLayouts[any],
+	"pointer":  slotLayouts[*layoutExample],
+	"struct":   slotLayouts[layoutExample],
+	"struct{}": slotLayouts[struct{}],
+}
+
+func writeLayouts(w io.Writer, layouts []structLayout) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, layout := range layouts {
+		fmt.Fprintf(tw, "%s: size %d, align %d, padding %d\n", layout.Name, layout.Size, layout.Align, layout.padding())
+		fmt.Fprintln(tw, "FIELD\tTYPE\tOFFSET\tSIZE\tALIGN\tPADDING")
+		for _, field := range layout.Fields {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", field.Name, field.Type, field.Offset, field.Size, field.Align, field.Padding)
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+// runLayout implements the layout command: it prints the field offsets and
+// padding of the slot structs of a HashTable for the given value types.
+func runLayout(args []string) error {
+	flags := flag.NewFlagSet("layout", flag.ContinueOnError)
+	values := flags.String("value", "int", "comma separated value types, or all")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var names []string
+	if *values == "all" {
+		for name := range layoutValueTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	} else {
+		names = strings.Split(*values, ",")
+	}
+	if len(names) == 0 {
+		return errors.New("layout: no value type given")
+	}
+
+	for _, name := range names {
+		layouts, ok := layoutValueTypes[name]
+		if !ok {
+			return fmt.Errorf("unknown value type %q", name)
+		}
+		fmt.Printf("== V = %s\n", name)
+		if err := writeLayouts(os.Stdout, layouts()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func skipUnless64Bit(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("layout expectations assume a 64-bit platform")
+	}
+}
+
+// The slot layouts are tracked here so that changes to data or nodeKey that
+// grow the slots show up as test failures.
+func TestSlotLayouts(t *testing.T) {
+	skipUnless64Bit(t)
+	tests := []struct {
+		value   string
+		size    uintptr
+		padding uintptr
+	}{
+		{"int", 40, 7},
+		{"bool", 32, 6},
+		{"string", 48, 7},
+		{"any", 48, 7},
+		{"struct{}", 32, 7},
+	}
+	for _, test := range tests {
+		layout := layoutValueTypes[test.value]()[0]
+		if layout.Size != test.size {
+			t.Errorf("data[%s] size = %d, expected: %d", test.value, layout.Size, test.size)
+		}
+		if layout.padding() != test.padding {
+			t.Errorf("data[%s] padding = %d, expected: %d", test.value, layout.padding(), test.padding)
+		}
+	}
+
+	keyLayout := slotLayouts[int]()[1]
+	if keyLayout.Size != 24 || keyLayout.padding() != 0 {
+		t.Errorf("nodeKey size = %d, padding = %d, expected: 24 and 0", keyLayout.Size, keyLayout.padding())
+	}
+}
+
+func TestLayoutOfFieldOffsets(t *testing.T) {
+	skipUnless64Bit(t)
+	layout := layoutValueTypes["struct"]()[0]
+	value := layout.Fields[1]
+	if value.Name != "value" || value.Offset != 24 || value.Size != 16 {
+		t.Errorf("data[layoutExample].value = %+v, expected offset 24 and size 16", value)
+	}
+	var total uintptr
+	for _, field := range layout.Fields {
+		total += field.Size + field.Padding
+	}
+	if total != layout.Size {
+		t.Errorf("fields and padding add up to %d, expected the struct size %d", total, layout.Size)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	skipUnless64Bit(t)
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	hashTable.Insert("foo-22", 2)
+	hashTable.Insert("foo-333", 3)
+	hashTable.Delete("foo-333")
+
+	usage := hashTable.MemoryUsage()
+	if usage.SlotSize != 40 {
+		t.Errorf("MemoryUsage().SlotSize = %d, expected: 40", usage.SlotSize)
+	}
+	if usage.SlotBytes != 40*17 {
+		t.Errorf("MemoryUsage().SlotBytes = %d, expected: %d", usage.SlotBytes, 40*17)
+	}
+	if usage.KeyBytes != 5+6+7 {
+		t.Errorf("MemoryUsage().KeyBytes = %d, expected tombstone keys to be included: %d", usage.KeyBytes, 5+6+7)
+	}
+	expectedPerEntry := float64(usage.TotalBytes) / 2
+	if usage.BytesPerEntry != expectedPerEntry {
+		t.Errorf("MemoryUsage().BytesPerEntry = %f, expected: %f", usage.BytesPerEntry, expectedPerEntry)
+	}
+	expectedOverhead := (float64(usage.TotalBytes) - (5 + 6) - 2*8) / 2
+	if usage.OverheadPerEntry != expectedOverhead {
+		t.Errorf("MemoryUsage().OverheadPerEntry = %f, expected: %f", usage.OverheadPerEntry, expectedOverhead)
+	}
+
+	empty := New[int](10).MemoryUsage()
+	if empty.BytesPerEntry != 0 || empty.OverheadPerEntry != 0 {
+		t.Errorf("MemoryUsage() of an empty table = %+v, expected zero per entry figures", empty)
+	}
+}
+
+func TestWriteLayouts(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLayouts(&buf, slotLayouts[int]()); err != nil {
+		t.Fatalf("writeLayouts returned error: %v", err)
+	}
+	for _, expected := range []string{"main.data[int]", "main.nodeKey", "state", "PADDING"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("layout output is missing %s:\n%s", expected, buf.String())
+		}
+	}
+}
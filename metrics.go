@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const metricsNamespace string = "golookup_table"
+
+// StatsProvider is implemented by every HashTable instantiation.
+type StatsProvider interface {
+	Stats() Stats
+}
+
+// StatsFunc adapts a function to a StatsProvider. Since a HashTable is not
+// safe for concurrent use, tables that are modified while metrics are being
+// scraped should be registered through a StatsFunc that holds the same lock
+// as their writers.
+type StatsFunc func() Stats
+
+func (f StatsFunc) Stats() Stats {
+	return f()
+}
+
+// MetricsRegistry publishes the Stats of named tables through expvar and as
+// a Prometheus text exposition HTTP handler.
+type MetricsRegistry struct {
+	mu     sync.Mutex
+	tables map[string]StatsProvider
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{tables: make(map[string]StatsProvider)}
+}
+
+func (r *MetricsRegistry) Register(name string, table StatsProvider) error {
+	if name == "" {
+		return errors.New("table name can't be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tables[name]; ok {
+		return fmt.Errorf("table %q is already registered", name)
+	}
+	r.tables[name] = table
+	return nil
+}
+
+func (r *MetricsRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tables, name)
+}
+
+type namedStats struct {
+	name  string
+	stats Stats
+}
+
+// collect returns the stats of every registered table, sorted by name.
+func (r *MetricsRegistry) collect() []namedStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	collected := make([]namedStats, 0, len(r.tables))
+	for name, table := range r.tables {
+		collected = append(collected, namedStats{name: name, stats: table.Stats()})
+	}
+	sort.Slice(collected, func(i, j int) bool {
+		return collected[i].name < collected[j].name
+	})
+	return collected
+}
+
+// PublishExpvar exposes the registry as an expvar variable holding a JSON
+// object of Stats keyed by table name. Like expvar.Publish, names are global
+// to the process, but a duplicate name is reported as an error instead of a panic.
+func (r *MetricsRegistry) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar %q is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		tables := make(map[string]Stats)
+		for _, table := range r.collect() {
+			tables[table.name] = table.stats
+		}
+		return tables
+	}))
+	return nil
+}
+
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := r.WritePrometheus(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// WritePrometheus writes the metrics of every registered table in the
+// Prometheus text exposition format.
+func (r *MetricsRegistry) WritePrometheus(w io.Writer) error {
+	tables := r.collect()
+	bw := bufio.NewWriter(w)
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(s Stats) float64
+	}{
+		{"live_entries", "Number of keys stored in the table.", func(s Stats) float64 { return float64(s.Live) }},
+		{"tombstones", "Number of slots holding a tombstone.", func(s Stats) float64 { return float64(s.Tombstones) }},
+		{"capacity", "Number of slots of the table.", func(s Stats) float64 { return float64(s.Capacity) }},
+		{"load_factor", "Ratio of live and tombstone slots to capacity.", func(s Stats) float64 { return float64(s.LoadFactor) }},
+		{"tombstone_ratio", "Ratio of tombstone slots to capacity.", func(s Stats) float64 {
+			if s.Capacity == 0 {
+				return 0
+			}
+			return float64(s.Tombstones) / float64(s.Capacity)
+		}},
+	}
+	for _, gauge := range gauges {
+		writeMetricHeader(bw, gauge.name, gauge.help, "gauge")
+		for _, table := range tables {
+			writeSample(bw, gauge.name, labels("table", table.name), gauge.value(table.stats))
+		}
+	}
+
+	writeMetricHeader(bw, "operations_total", "Number of operations performed on the table.", "counter")
+	for _, table := range tables {
+		writeSample(bw, "operations_total", labels("table", table.name, "op", "insert"), float64(table.stats.Inserts.Operations))
+		writeSample(bw, "operations_total", labels("table", table.name, "op", "lookup"), float64(table.stats.Lookups.Operations))
+		writeSample(bw, "operations_total", labels("table", table.name, "op", "delete"), float64(table.stats.Deletes))
+	}
+
+	writeMetricHeader(bw, "resizes_total", "Number of times the table was resized.", "counter")
+	for _, table := range tables {
+		writeSample(bw, "resizes_total", labels("table", table.name, "direction", "grow"), float64(table.stats.Grows))
+		writeSample(bw, "resizes_total", labels("table", table.name, "direction", "shrink"), float64(table.stats.Shrinks))
+	}
+
+	writeMetricHeader(bw, "resize_seconds_total", "Time spent resizing the table.", "counter")
+	for _, table := range tables {
+		writeSample(bw, "resize_seconds_total", labels("table", table.name), table.stats.ResizeTime.Seconds())
+	}
+
+	writeMetricHeader(bw, "probe_length", "Number of slots probed past the home slot.", "histogram")
+	for _, table := range tables {
+		writeProbeHistogram(bw, table.name, "insert", table.stats.Inserts)
+		writeProbeHistogram(bw, table.name, "lookup", table.stats.Lookups)
+	}
+
+	return bw.Flush()
+}
+
+func writeMetricHeader(w *bufio.Writer, name string, help string, kind string) {
+	fmt.Fprintf(w, "# HELP %s_%s %s\n", metricsNamespace, name, help)
+	fmt.Fprintf(w, "# TYPE %s_%s %s\n", metricsNamespace, name, kind)
+}
+
+func writeSample(w *bufio.Writer, name string, labels string, value float64) {
+	fmt.Fprintf(w, "%s_%s{%s} %s\n", metricsNamespace, name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// writeProbeHistogram converts a probe length histogram into cumulative
+// Prometheus buckets. The last histogram bucket is open ended, so it is only
+// represented by the +Inf bucket.
+func writeProbeHistogram(w *bufio.Writer, table string, op string, probes ProbeStats) {
+	var cumulative uint64
+	for i := 0; i < len(probes.Histogram)-1; i++ {
+		cumulative += probes.Histogram[i]
+		writeSample(w, "probe_length_bucket", labels("table", table, "op", op, "le", strconv.Itoa(i)), float64(cumulative))
+	}
+	writeSample(w, "probe_length_bucket", labels("table", table, "op", op, "le", "+Inf"), float64(probes.Operations))
+	writeSample(w, "probe_length_sum", labels("table", table, "op", op), float64(probes.TotalProbes))
+	writeSample(w, "probe_length_count", labels("table", table, "op", op), float64(probes.Operations))
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// labels formats name/value pairs as a Prometheus label set.
+func labels(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i < len(pairs); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(pairs[i])
+		b.WriteString(`="`)
+		b.WriteString(labelValueEscaper.Replace(pairs[i+1]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMetricsRegistryRegister(t *testing.T) {
+	registry := NewMetricsRegistry()
+	if err := registry.Register("users", New[int](10)); err != nil {
+		t.Fatalf("Register(users) returned error: %v", err)
+	}
+	if err := registry.Register("users", New[int](10)); err == nil {
+		t.Errorf("Register(users) twice = nil, expected an error")
+	}
+	if err := registry.Register("", New[int](10)); err == nil {
+		t.Errorf("Register with an empty name = nil, expected an error")
+	}
+	registry.Unregister("users")
+	if err := registry.Register("users", New[string](10)); err != nil {
+		t.Errorf("Register(users) after Unregister returned error: %v", err)
+	}
+}
+
+func TestMetricsPrometheusHandler(t *testing.T) {
+	users := New[int](10)
+	users.EnableLookupStats()
+	users.Insert("foo-1", 1)
+	users.Insert("foo-2", 2)
+	users.Insert("foo-3", 3)
+	users.Delete("foo-2")
+	users.Search("foo-1")
+
+	registry := NewMetricsRegistry()
+	registry.Register("users", users)
+	registry.Register(`odd"name`, New[string](10))
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+
+	if !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %s, expected Prometheus text format", recorder.Header().Get("Content-Type"))
+	}
+	expectedLines := []string{
+		"# TYPE golookup_table_live_entries gauge",
+		`golookup_table_live_entries{table="users"} 2`,
+		`golookup_table_tombstones{table="users"} 1`,
+		`golookup_table_capacity{table="users"} 17`,
+		`golookup_table_operations_total{table="users",op="insert"} 3`,
+		`golookup_table_operations_total{table="users",op="lookup"} 2`,
+		`golookup_table_operations_total{table="users",op="delete"} 1`,
+		`golookup_table_resizes_total{table="users",direction="grow"} 0`,
+		"# TYPE golookup_table_probe_length histogram",
+		`golookup_table_probe_length_bucket{table="users",op="insert",le="+Inf"} 3`,
+		`golookup_table_probe_length_count{table="users",op="lookup"} 2`,
+		`golookup_table_live_entries{table="odd\"name"} 0`,
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics output is missing line: %s\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsPrometheusBucketsAreCumulative(t *testing.T) {
+	var tableLength uint64 = 389
+	table := buildHashTable(findCollidingKeys(20, tableLength), tableLength)
+	registry := NewMetricsRegistry()
+	registry.Register("colliding", table)
+
+	var output strings.Builder
+	if err := registry.WritePrometheus(&output); err != nil {
+		t.Fatalf("WritePrometheus returned error: %v", err)
+	}
+
+	var previous float64 = -1
+	for _, line := range strings.Split(output.String(), "\n") {
+		if !strings.HasPrefix(line, `golookup_table_probe_length_bucket{table="colliding",op="insert"`) {
+			continue
+		}
+		fields := strings.Fields(line)
+		value, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			t.Fatalf("invalid sample value in line %s: %v", line, err)
+		}
+		if value < previous {
+			t.Errorf("bucket %s is lower than the previous bucket %v", line, previous)
+		}
+		previous = value
+	}
+	if previous != 20 {
+		t.Errorf("+Inf bucket = %v, expected: 20", previous)
+	}
+}
+
+func TestMetricsPublishExpvar(t *testing.T) {
+	var mu sync.Mutex
+	users := New[int](10)
+	users.Insert("foo-1", 1)
+
+	registry := NewMetricsRegistry()
+	registry.Register("users", StatsFunc(func() Stats {
+		mu.Lock()
+		defer mu.Unlock()
+		return users.Stats()
+	}))
+
+	if err := registry.PublishExpvar("golookup_test_tables"); err != nil {
+		t.Fatalf("PublishExpvar returned error: %v", err)
+	}
+	if err := registry.PublishExpvar("golookup_test_tables"); err == nil {
+		t.Errorf("PublishExpvar twice = nil, expected an error")
+	}
+
+	var published map[string]Stats
+	if err := json.Unmarshal([]byte(expvar.Get("golookup_test_tables").String()), &published); err != nil {
+		t.Fatalf("expvar output is not valid JSON: %v", err)
+	}
+	if published["users"].Live != 1 {
+		t.Errorf("published users.Live = %d, expected: 1", published["users"].Live)
+	}
+}
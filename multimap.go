@@ -0,0 +1,100 @@
+package main
+
+import (
+	"iter"
+	"slices"
+)
+
+// MultiHashTable maps every key to a list of values. The lists are stored as
+// the values of a HashTable and appended to in place, so adding a value
+// probes the table once and never re-inserts the list. A key is deleted as
+// soon as its last value is removed.
+type MultiHashTable[V comparable] struct {
+	table  *HashTable[[]V]
+	values int
+}
+
+func NewMulti[V comparable](length uint64) *MultiHashTable[V] {
+	return &MultiHashTable[V]{table: New[[]V](length)}
+}
+
+// Len returns the number of keys with at least one value.
+func (m *MultiHashTable[V]) Len() int {
+	return m.table.Len()
+}
+
+// Values returns the number of values of all keys.
+func (m *MultiHashTable[V]) Values() int {
+	return m.values
+}
+
+// Count returns the number of values of key.
+func (m *MultiHashTable[V]) Count(key string) int {
+	if list := m.table.lookup(key); list != nil {
+		return len(*list)
+	}
+	return 0
+}
+
+// Add appends value to the values of key. A key may hold the same value
+// more than once.
+func (m *MultiHashTable[V]) Add(key string, value V) {
+	list := m.table.upsert(key)
+	*list = append(*list, value)
+	m.values++
+}
+
+// GetAll returns an iterator over the values of key in the order they were
+// added. The table must not be modified while iterating.
+func (m *MultiHashTable[V]) GetAll(key string) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		list := m.table.lookup(key)
+		if list == nil {
+			return
+		}
+		for _, value := range *list {
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+// Remove removes the first occurrence of value from the values of key and
+// reports whether it was found.
+func (m *MultiHashTable[V]) Remove(key string, value V) bool {
+	list := m.table.lookup(key)
+	if list == nil {
+		return false
+	}
+	i := slices.Index(*list, value)
+	if i < 0 {
+		return false
+	}
+	m.values--
+	if len(*list) == 1 {
+		m.table.Delete(key)
+		return true
+	}
+	*list = slices.Delete(*list, i, i+1)
+	return true
+}
+
+// RemoveAll deletes key with all its values and returns how many values it
+// had.
+func (m *MultiHashTable[V]) RemoveAll(key string) int {
+	list := m.table.lookup(key)
+	if list == nil {
+		return 0
+	}
+	count := len(*list)
+	m.values -= count
+	m.table.Delete(key)
+	return count
+}
+
+// All returns an iterator over the keys and their values in slot order. The
+// values slice must not be modified.
+func (m *MultiHashTable[V]) All() iter.Seq2[string, []V] {
+	return m.table.All()
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestMultiAddGetAll(t *testing.T) {
+	multi := NewMulti[int](10)
+	multi.Add("tag-a", 1)
+	multi.Add("tag-a", 2)
+	multi.Add("tag-a", 1)
+	multi.Add("tag-b", 3)
+
+	if got := slices.Collect(multi.GetAll("tag-a")); !slices.Equal(got, []int{1, 2, 1}) {
+		t.Errorf("GetAll(tag-a) = %v, expected: [1 2 1]", got)
+	}
+	if got := slices.Collect(multi.GetAll("missing")); len(got) != 0 {
+		t.Errorf("GetAll(missing) = %v, expected no values", got)
+	}
+	if multi.Len() != 2 || multi.Values() != 4 || multi.Count("tag-a") != 3 || multi.Count("missing") != 0 {
+		t.Errorf("Len() = %d, Values() = %d, Count(tag-a) = %d, expected: 2, 4, 3", multi.Len(), multi.Values(), multi.Count("tag-a"))
+	}
+}
+
+func TestMultiRemove(t *testing.T) {
+	multi := NewMulti[string](10)
+	multi.Add("tag", "x")
+	multi.Add("tag", "y")
+	multi.Add("tag", "x")
+
+	if !multi.Remove("tag", "x") {
+		t.Errorf("Remove(tag, x) = false, expected the value to be found")
+	}
+	if got := slices.Collect(multi.GetAll("tag")); !slices.Equal(got, []string{"y", "x"}) {
+		t.Errorf("GetAll(tag) = %v, expected only the first x to be removed: [y x]", got)
+	}
+	if multi.Remove("tag", "z") || multi.Remove("missing", "x") {
+		t.Errorf("Remove of a missing value = true, expected false")
+	}
+
+	multi.Remove("tag", "y")
+	multi.Remove("tag", "x")
+	if multi.Len() != 0 || multi.Values() != 0 {
+		t.Errorf("Len() = %d, Values() = %d, expected the key to be deleted with its last value", multi.Len(), multi.Values())
+	}
+}
+
+func TestMultiRemoveAll(t *testing.T) {
+	multi := NewMulti[int](10)
+	for i := 0; i < 1000; i++ {
+		multi.Add(fmt.Sprintf("tag-%d", i%10), i)
+	}
+	if removed := multi.RemoveAll("tag-3"); removed != 100 {
+		t.Errorf("RemoveAll(tag-3) = %d, expected: 100", removed)
+	}
+	if removed := multi.RemoveAll("tag-3"); removed != 0 {
+		t.Errorf("RemoveAll(tag-3) twice = %d, expected: 0", removed)
+	}
+	if multi.Len() != 9 || multi.Values() != 900 {
+		t.Errorf("Len() = %d, Values() = %d, expected: 9, 900", multi.Len(), multi.Values())
+	}
+}
+
+func TestUpsertAndLookup(t *testing.T) {
+	table := New[int](10)
+	for i := 0; i < 100; i++ {
+		*table.upsert(fmt.Sprintf("key-%d", i%20)) += i
+	}
+	if table.Len() != 20 {
+		t.Errorf("Len() = %d, expected: 20", table.Len())
+	}
+	if value := table.lookup("key-3"); value == nil || *value != 3+23+43+63+83 {
+		t.Errorf("lookup(key-3) = %v, expected: %d", value, 3+23+43+63+83)
+	}
+	if table.lookup("missing") != nil {
+		t.Errorf("lookup(missing) != nil, expected nil")
+	}
+
+	// upsert must reuse a tombstone like insert does.
+	keys := findCollidingKeys(3, table.length)
+	for _, key := range keys {
+		table.Insert(key, 1)
+	}
+	table.Delete(keys[0])
+	occupied := table.occupiedSlotCounter
+	*table.upsert(keys[0]) = 5
+	if table.occupiedSlotCounter != occupied {
+		t.Errorf("occupiedSlotCounter = %d, expected the tombstone to be reused: %d", table.occupiedSlotCounter, occupied)
+	}
+	if err := table.Validate(); err != nil {
+		t.Errorf("Validate() after upsert: %v", err)
+	}
+}
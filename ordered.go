@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"iter"
+)
+
+// noEntry marks the end of the entry list of an OrderedHashTable.
+const noEntry int = -1
+
+type orderedEntry[V any] struct {
+	key        string
+	value      V
+	prev, next int
+}
+
+// OrderedHashTable is a HashTable that remembers the order of its keys. The
+// key-value pairs live in a dense entries array, linked into a list from the
+// oldest to the newest key, and a HashTable maps every key to its entry. Since
+// resizing the index doesn't move entries, the order is stable across resizes.
+type OrderedHashTable[V any] struct {
+	index   *HashTable[int]
+	entries []orderedEntry[V]
+	head    int
+	tail    int
+	// Number of entries of deleted keys, which are dropped by compact.
+	holes int
+}
+
+func NewOrdered[V any](length uint64) *OrderedHashTable[V] {
+	return &OrderedHashTable[V]{
+		index: New[int](length),
+		head:  noEntry,
+		tail:  noEntry,
+	}
+}
+
+func (o *OrderedHashTable[V]) Len() int {
+	return o.index.Len()
+}
+
+func (o *OrderedHashTable[V]) unlink(i int) {
+	entry := &o.entries[i]
+	if entry.prev == noEntry {
+		o.head = entry.next
+	} else {
+		o.entries[entry.prev].next = entry.next
+	}
+	if entry.next == noEntry {
+		o.tail = entry.prev
+	} else {
+		o.entries[entry.next].prev = entry.prev
+	}
+}
+
+func (o *OrderedHashTable[V]) linkBack(i int) {
+	o.entries[i].prev = o.tail
+	o.entries[i].next = noEntry
+	if o.tail == noEntry {
+		o.head = i
+	} else {
+		o.entries[o.tail].next = i
+	}
+	o.tail = i
+}
+
+func (o *OrderedHashTable[V]) linkFront(i int) {
+	o.entries[i].prev = noEntry
+	o.entries[i].next = o.head
+	if o.head == noEntry {
+		o.tail = i
+	} else {
+		o.entries[o.head].prev = i
+	}
+	o.head = i
+}
+
+// Insert adds key as the newest key of the table. Updating the value of an
+// existing key keeps its position.
+func (o *OrderedHashTable[V]) Insert(key string, value V) {
+	if i, err := o.index.Search(key); err == nil {
+		o.entries[i].value = value
+		return
+	}
+	o.index.Insert(key, len(o.entries))
+	o.entries = append(o.entries, orderedEntry[V]{key: key, value: value})
+	o.linkBack(len(o.entries) - 1)
+}
+
+func (o *OrderedHashTable[V]) Search(key string) (V, error) {
+	i, err := o.index.Search(key)
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return o.entries[i].value, nil
+}
+
+func (o *OrderedHashTable[V]) Delete(key string) error {
+	i, err := o.index.Search(key)
+	if err != nil {
+		return err
+	}
+	o.index.Delete(key)
+	o.unlink(i)
+	o.entries[i] = orderedEntry[V]{}
+	o.holes++
+
+	if o.holes > len(o.entries)/2 {
+		o.compact()
+	}
+	return nil
+}
+
+// compact rewrites the entries in list order without the deleted ones and
+// points the index at the new positions.
+func (o *OrderedHashTable[V]) compact() {
+	entries := make([]orderedEntry[V], 0, o.Len())
+	for i := o.head; i != noEntry; i = o.entries[i].next {
+		entry := o.entries[i]
+		entry.prev = len(entries) - 1
+		entry.next = len(entries) + 1
+		o.index.Insert(entry.key, len(entries))
+		entries = append(entries, entry)
+	}
+	o.head, o.tail = noEntry, noEntry
+	if len(entries) > 0 {
+		entries[len(entries)-1].next = noEntry
+		o.head, o.tail = 0, len(entries)-1
+	}
+	o.entries = entries
+	o.holes = 0
+}
+
+// MoveToFront makes key the oldest key of the table.
+func (o *OrderedHashTable[V]) MoveToFront(key string) error {
+	i, err := o.index.Search(key)
+	if err != nil {
+		return err
+	}
+	o.unlink(i)
+	o.linkFront(i)
+	return nil
+}
+
+// MoveToBack makes key the newest key of the table.
+func (o *OrderedHashTable[V]) MoveToBack(key string) error {
+	i, err := o.index.Search(key)
+	if err != nil {
+		return err
+	}
+	o.unlink(i)
+	o.linkBack(i)
+	return nil
+}
+
+// Oldest returns the first key in the order of the table. ok is false if
+// the table is empty.
+func (o *OrderedHashTable[V]) Oldest() (key string, value V, ok bool) {
+	if o.head == noEntry {
+		return "", value, false
+	}
+	entry := &o.entries[o.head]
+	return entry.key, entry.value, true
+}
+
+// Newest returns the last key in the order of the table. ok is false if the
+// table is empty.
+func (o *OrderedHashTable[V]) Newest() (key string, value V, ok bool) {
+	if o.tail == noEntry {
+		return "", value, false
+	}
+	entry := &o.entries[o.tail]
+	return entry.key, entry.value, true
+}
+
+// All returns an iterator over the key-value pairs from the oldest to the
+// newest key. The table must not be modified while iterating.
+func (o *OrderedHashTable[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for i := o.head; i != noEntry; i = o.entries[i].next {
+			if !yield(o.entries[i].key, o.entries[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the key-value pairs from the newest to
+// the oldest key. The table must not be modified while iterating.
+func (o *OrderedHashTable[V]) Backward() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		for i := o.tail; i != noEntry; i = o.entries[i].prev {
+			if !yield(o.entries[i].key, o.entries[i].value) {
+				return
+			}
+		}
+	}
+}
+
+// WriteJSON streams the table to w as a JSON object with the members in the
+// order of the table.
+func (o *OrderedHashTable[V]) WriteJSON(w io.Writer) error {
+	return writeJSONObject(w, o.All())
+}
+
+// ReadJSON decodes a JSON object from r, inserting the members in document
+// order. Existing entries are kept and keep their position when overwritten.
+func (o *OrderedHashTable[V]) ReadJSON(r io.Reader) error {
+	return readJSONObject(r, func() {
+		if o.index == nil {
+			*o = *NewOrdered[V](0)
+		}
+	}, o.Insert)
+}
+
+func (o *OrderedHashTable[V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := o.WriteJSON(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (o *OrderedHashTable[V]) UnmarshalJSON(b []byte) error {
+	return o.ReadJSON(bytes.NewReader(b))
+}
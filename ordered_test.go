@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+)
+
+func orderedKeys[V any](entries iter.Seq2[string, V]) []string {
+	var keys []string
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestOrderedInsertionOrder(t *testing.T) {
+	table := NewOrdered[int](10)
+	var expected []string
+	// Enough keys to resize the index several times.
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key-%d", 999-i)
+		table.Insert(key, i)
+		expected = append(expected, key)
+	}
+	table.Insert("key-500", -1)
+
+	if got := orderedKeys(table.All()); !slices.Equal(got, expected) {
+		t.Errorf("All() is not in insertion order, first keys: %v", got[:5])
+	}
+	slices.Reverse(expected)
+	if got := orderedKeys(table.Backward()); !slices.Equal(got, expected) {
+		t.Errorf("Backward() is not in reverse insertion order, first keys: %v", got[:5])
+	}
+	if value, err := table.Search("key-500"); err != nil || value != -1 {
+		t.Errorf("Search(key-500) = %d, want -1, error: %v", value, err)
+	}
+}
+
+func TestOrderedDeleteAndCompact(t *testing.T) {
+	table := NewOrdered[int](10)
+	for i := 0; i < 100; i++ {
+		table.Insert(fmt.Sprintf("key-%d", i), i)
+	}
+	var expected []string
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if i%3 == 0 {
+			expected = append(expected, key)
+			continue
+		}
+		if err := table.Delete(key); err != nil {
+			t.Fatalf("Delete(%s) returned error: %v", key, err)
+		}
+	}
+
+	if len(table.entries) >= 100 {
+		t.Errorf("%d entries left after deleting two thirds of the keys, expected a compaction", len(table.entries))
+	}
+	if got := orderedKeys(table.All()); !slices.Equal(got, expected) {
+		t.Errorf("All() = %v, expected: %v", got, expected)
+	}
+	for _, key := range expected {
+		if _, err := table.Search(key); err != nil {
+			t.Errorf("Search(%s) after compaction returned error: %v", key, err)
+		}
+	}
+	if err := table.Delete("key-1"); err == nil {
+		t.Errorf("Delete(key-1) twice = nil, expected an error")
+	}
+}
+
+func TestOrderedMoveOldestNewest(t *testing.T) {
+	table := NewOrdered[int](10)
+	if _, _, ok := table.Oldest(); ok {
+		t.Errorf("Oldest() of an empty table returned ok")
+	}
+	for i, key := range []string{"a", "b", "c", "d"} {
+		table.Insert(key, i)
+	}
+
+	table.MoveToFront("c")
+	table.MoveToBack("a")
+	if got := orderedKeys(table.All()); !slices.Equal(got, []string{"c", "b", "d", "a"}) {
+		t.Errorf("All() = %v, expected: [c b d a]", got)
+	}
+	if key, value, ok := table.Oldest(); !ok || key != "c" || value != 2 {
+		t.Errorf("Oldest() = %s, %d, %v, expected: c, 2, true", key, value, ok)
+	}
+	if key, value, ok := table.Newest(); !ok || key != "a" || value != 0 {
+		t.Errorf("Newest() = %s, %d, %v, expected: a, 0, true", key, value, ok)
+	}
+	if err := table.MoveToFront("missing"); err == nil {
+		t.Errorf("MoveToFront(missing) = nil, expected an error")
+	}
+
+	for _, key := range []string{"a", "b", "c", "d"} {
+		table.Delete(key)
+	}
+	if _, _, ok := table.Newest(); ok {
+		t.Errorf("Newest() after deleting every key returned ok")
+	}
+}
+
+func TestOrderedJSONKeepsOrder(t *testing.T) {
+	document := `{"zeta":1,"alpha":2,"mid":3}`
+	var table OrderedHashTable[int]
+	if err := json.Unmarshal([]byte(document), &table); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	encoded, err := json.Marshal(&table)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	if string(encoded) != document {
+		t.Errorf("json.Marshal = %s, expected: %s", encoded, document)
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const walFileName string = "wal.log"
+const snapshotFileName string = "snapshot.gob"
+
+// Every log record starts with a header holding the payload length and the
+// CRC-32 (Castagnoli) checksum of the payload.
+const walHeaderSize int = 8
+
+// Records larger than this are treated as corruption rather than allocated.
+const walMaxRecordSize uint32 = 64 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errWALCorrupt marks a record whose length or checksum is invalid.
+var errWALCorrupt = errors.New("corrupted log record")
+
+// SyncPolicy defines when the write-ahead log is flushed to stable storage.
+type SyncPolicy uint8
+
+const (
+	// Every record is fsynced before Insert or Delete returns.
+	SyncAlways SyncPolicy = iota
+	// The log is fsynced by the first write that happens at least
+	// SyncInterval after the previous fsync, and on Close.
+	SyncInterval
+	// The log is only fsynced by Snapshot, otherwise the operating system
+	// decides when it reaches stable storage.
+	SyncNever
+)
+
+const (
+	walOpInsert uint8 = iota + 1
+	walOpDelete
+)
+
+type walRecord[V any] struct {
+	Op    uint8
+	Key   string
+	Value V
+}
+
+type PersistentOptions struct {
+	Sync         SyncPolicy
+	SyncInterval time.Duration
+	// A snapshot is taken, and the log truncated, after this many log
+	// records. Zero disables automatic snapshots.
+	SnapshotEvery int
+}
+
+// PersistentHashTable is a HashTable whose mutations are appended to a
+// write-ahead log before they are applied, so its contents survive a crash.
+// On open, the latest snapshot is loaded and the log is replayed on top of it.
+// A torn or corrupted last record, as left behind by a crash in the middle of
+// a write, is discarded. A corrupted record followed by others fails the open
+// and leaves the log as it is.
+type PersistentHashTable[V any] struct {
+	table      *HashTable[V]
+	dir        string
+	options    PersistentOptions
+	log        *os.File
+	logRecords int
+	lastSync   time.Time
+	// failed is set when a failed append could not be removed from the log.
+	// Later records would follow the torn one and be lost on replay, so every
+	// further write returns it.
+	failed error
+}
+
+func OpenPersistent[V any](dir string, options PersistentOptions) (*PersistentHashTable[V], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	p := &PersistentHashTable[V]{
+		table:    New[V](0),
+		dir:      dir,
+		options:  options,
+		lastSync: time.Now(),
+	}
+
+	if err := p.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	p.log = log
+
+	if err := p.replay(); err != nil {
+		log.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *PersistentHashTable[V]) loadSnapshot() error {
+	encoded, err := os.ReadFile(filepath.Join(p.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := p.table.GobDecode(encoded); err != nil {
+		return fmt.Errorf("loading snapshot: %w", err)
+	}
+	return nil
+}
+
+// replay applies every valid record of the log to the table and truncates
+// the log after the last valid record, leaving the file offset at its end.
+// Only a torn or corrupted last record ends the log; any other error is
+// returned and the log is left untouched.
+func (p *PersistentHashTable[V]) replay() error {
+	info, err := p.log.Stat()
+	if err != nil {
+		return err
+	}
+	offset, err := p.replayRecords(bufio.NewReader(p.log), info.Size())
+	if err != nil {
+		return err
+	}
+	if err := p.log.Truncate(offset); err != nil {
+		return err
+	}
+	_, err = p.log.Seek(offset, io.SeekStart)
+	return err
+}
+
+// replayRecords applies the records read from r, a log of size bytes, and
+// returns the offset after the last valid one. A corrupted record is only
+// taken for the torn end of the log if it reaches the end of the file.
+func (p *PersistentHashTable[V]) replayRecords(r io.Reader, size int64) (int64, error) {
+	var offset int64
+	for {
+		payload, recordSize, err := readWALRecord(r)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return offset, nil
+		}
+		if errors.Is(err, errWALCorrupt) && offset+recordSize >= size {
+			return offset, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("replaying log at offset %d: %w", offset, err)
+		}
+
+		var record walRecord[V]
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record); err != nil {
+			return 0, fmt.Errorf("decoding log record at offset %d: %w", offset, err)
+		}
+		p.apply(record)
+		p.logRecords++
+		offset += recordSize
+	}
+}
+
+// readWALRecord returns the payload of the next record and the size of the
+// record, header included, as given by its header. io.EOF and
+// io.ErrUnexpectedEOF mean the log ends before the record, errWALCorrupt
+// that the record is invalid.
+func readWALRecord(r io.Reader) ([]byte, int64, error) {
+	var header [walHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+	length := binary.LittleEndian.Uint32(header[0:4])
+	checksum := binary.LittleEndian.Uint32(header[4:8])
+	size := int64(walHeaderSize) + int64(length)
+	if length > walMaxRecordSize {
+		return nil, size, fmt.Errorf("%w: length %d exceeds maximum record size", errWALCorrupt, length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, size, err
+	}
+	if crc32.Checksum(payload, crcTable) != checksum {
+		return nil, size, fmt.Errorf("%w: checksum mismatch", errWALCorrupt)
+	}
+	return payload, size, nil
+}
+
+func (p *PersistentHashTable[V]) apply(record walRecord[V]) {
+	switch record.Op {
+	case walOpInsert:
+		p.table.Insert(record.Key, record.Value)
+	case walOpDelete:
+		// Replaying a log on top of a snapshot that already contains its
+		// effects can delete a key twice, so a missing key is not an error.
+		p.table.Delete(record.Key)
+	}
+}
+
+// append writes record to the log and syncs it as the sync policy asks. If
+// either fails the record is removed from the log again, so that it is
+// neither applied nor replayed.
+func (p *PersistentHashTable[V]) append(record walRecord[V]) error {
+	if p.failed != nil {
+		return p.failed
+	}
+	var payload bytes.Buffer
+	payload.Write(make([]byte, walHeaderSize))
+	if err := gob.NewEncoder(&payload).Encode(record); err != nil {
+		return err
+	}
+
+	buf := payload.Bytes()
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)-walHeaderSize))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(buf[walHeaderSize:], crcTable))
+	offset, err := p.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := p.log.Write(buf); err != nil {
+		p.unwrite(offset, err)
+		return err
+	}
+
+	switch p.options.Sync {
+	case SyncAlways:
+		err = p.Sync()
+	case SyncInterval:
+		if time.Since(p.lastSync) >= p.options.SyncInterval {
+			err = p.Sync()
+		}
+	}
+	if err != nil {
+		p.unwrite(offset, err)
+		return err
+	}
+	p.logRecords++
+	return nil
+}
+
+// unwrite removes the record written at offset after writing or syncing it
+// failed with err, so the next record doesn't follow a torn one and a failed
+// operation isn't replayed. If the log can't be cut back, the table refuses
+// further writes.
+func (p *PersistentHashTable[V]) unwrite(offset int64, err error) {
+	if _, seekErr := p.log.Seek(offset, io.SeekStart); seekErr != nil {
+		p.failed = fmt.Errorf("log is torn after a failed write: %w", err)
+	} else if truncErr := p.log.Truncate(offset); truncErr != nil {
+		p.failed = fmt.Errorf("log is torn after a failed write: %w", err)
+	}
+}
+
+func (p *PersistentHashTable[V]) maybeSnapshot() error {
+	if p.options.SnapshotEvery > 0 && p.logRecords >= p.options.SnapshotEvery {
+		return p.Snapshot()
+	}
+	return nil
+}
+
+// Insert logs and applies the insertion of key. If logging fails the error is
+// returned and the table is left unchanged, nor is the insertion replayed
+// later. An error of an automatic snapshot is returned after the insertion
+// was logged and applied.
+func (p *PersistentHashTable[V]) Insert(key string, value V) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	record := walRecord[V]{Op: walOpInsert, Key: key, Value: value}
+	if err := p.append(record); err != nil {
+		return err
+	}
+	p.apply(record)
+	return p.maybeSnapshot()
+}
+
+// Delete logs and applies the deletion of key, with the same guarantees on
+// errors as Insert.
+func (p *PersistentHashTable[V]) Delete(key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	if _, err := p.table.Search(key); err != nil {
+		return err
+	}
+	record := walRecord[V]{Op: walOpDelete, Key: key}
+	if err := p.append(record); err != nil {
+		return err
+	}
+	p.apply(record)
+	return p.maybeSnapshot()
+}
+
+func (p *PersistentHashTable[V]) Search(key string) (V, error) {
+	return p.table.Search(key)
+}
+
+// Sync flushes the log to stable storage.
+func (p *PersistentHashTable[V]) Sync() error {
+	if err := p.log.Sync(); err != nil {
+		return err
+	}
+	p.lastSync = time.Now()
+	return nil
+}
+
+// Snapshot atomically replaces the snapshot file with the current contents of
+// the table and then truncates the log. A crash between the two steps is
+// harmless: replaying the log on top of the new snapshot yields the same table.
+func (p *PersistentHashTable[V]) Snapshot() error {
+	encoded, err := p.table.GobEncode()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filepath.Join(p.dir, snapshotFileName+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(encoded); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(p.dir, snapshotFileName)); err != nil {
+		return err
+	}
+	if err := syncDir(p.dir); err != nil {
+		return err
+	}
+
+	if err := p.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := p.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	p.logRecords = 0
+	return p.Sync()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// Close flushes the log and closes it. No snapshot is taken.
+func (p *PersistentHashTable[V]) Close() error {
+	if p.options.Sync != SyncNever {
+		if err := p.Sync(); err != nil {
+			p.log.Close()
+			return err
+		}
+	}
+	return p.log.Close()
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func openPersistentTable(t *testing.T, dir string, options PersistentOptions) *PersistentHashTable[int] {
+	t.Helper()
+	table, err := OpenPersistent[int](dir, options)
+	if err != nil {
+		t.Fatalf("OpenPersistent(%s) returned error: %v", dir, err)
+	}
+	return table
+}
+
+func assertPersistentContents(t *testing.T, table *PersistentHashTable[int], expected map[string]int) {
+	t.Helper()
+	if table.table.activeSlotCounter != uint64(len(expected)) {
+		t.Errorf("table has %d items, expected: %d", table.table.activeSlotCounter, len(expected))
+	}
+	for key, want := range expected {
+		got, err := table.Search(key)
+		if err != nil || got != want {
+			t.Errorf("Search(%s) = %d, expected: %d, error: %v", key, got, want, err)
+		}
+	}
+}
+
+func TestPersistentReplayAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		t.Run(fmt.Sprintf("policy=%d", policy), func(t *testing.T) {
+			dir := filepath.Join(dir, fmt.Sprint(policy))
+			options := PersistentOptions{Sync: policy, SyncInterval: time.Millisecond}
+			table := openPersistentTable(t, dir, options)
+			expected := map[string]int{}
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("foo-%d", i)
+				if err := table.Insert(key, i); err != nil {
+					t.Fatalf("Insert(%s) returned error: %v", key, err)
+				}
+				expected[key] = i
+			}
+			for i := 0; i < 100; i += 3 {
+				key := fmt.Sprintf("foo-%d", i)
+				if err := table.Delete(key); err != nil {
+					t.Fatalf("Delete(%s) returned error: %v", key, err)
+				}
+				delete(expected, key)
+			}
+			table.Insert("foo-1", 1000)
+			expected["foo-1"] = 1000
+			if err := table.Close(); err != nil {
+				t.Fatalf("Close returned error: %v", err)
+			}
+
+			reopened := openPersistentTable(t, dir, options)
+			defer reopened.Close()
+			assertPersistentContents(t, reopened, expected)
+		})
+	}
+}
+
+func TestPersistentDeleteMissingKeyIsNotLogged(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{})
+	defer table.Close()
+
+	if err := table.Delete("foo-1"); err == nil {
+		t.Errorf("Delete(foo-1) = nil, expected an error")
+	}
+	info, err := os.Stat(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatalf("Stat returned error: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("log size = %d, expected: 0", info.Size())
+	}
+}
+
+func TestPersistentSnapshotTruncatesLog(t *testing.T) {
+	dir := t.TempDir()
+	options := PersistentOptions{SnapshotEvery: 10}
+	table := openPersistentTable(t, dir, options)
+	expected := map[string]int{}
+	for i := 0; i < 25; i++ {
+		key := fmt.Sprintf("foo-%d", i)
+		table.Insert(key, i)
+		expected[key] = i
+	}
+	if table.logRecords != 5 {
+		t.Errorf("logRecords = %d, expected: 5", table.logRecords)
+	}
+	if _, err := os.Stat(filepath.Join(dir, snapshotFileName)); err != nil {
+		t.Fatalf("expected snapshot file to exist, got error: %v", err)
+	}
+	table.Close()
+
+	reopened := openPersistentTable(t, dir, options)
+	defer reopened.Close()
+	assertPersistentContents(t, reopened, expected)
+}
+
+func TestPersistentReplayIsIdempotentOverSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{})
+	table.Insert("foo-1", 1)
+	table.Insert("foo-2", 2)
+	table.Delete("foo-1")
+	log, _ := os.ReadFile(filepath.Join(dir, walFileName))
+	table.Snapshot()
+	table.Close()
+
+	// Simulate a crash after the snapshot was renamed but before the log was
+	// truncated.
+	os.WriteFile(filepath.Join(dir, walFileName), log, 0o644)
+
+	reopened := openPersistentTable(t, dir, PersistentOptions{})
+	defer reopened.Close()
+	assertPersistentContents(t, reopened, map[string]int{"foo-2": 2})
+}
+
+func TestPersistentRecoversFromTornRecord(t *testing.T) {
+	for _, cut := range []int64{1, int64(walHeaderSize / 2), int64(walHeaderSize + 3)} {
+		t.Run(fmt.Sprintf("cut=%d", cut), func(t *testing.T) {
+			dir := t.TempDir()
+			table := openPersistentTable(t, dir, PersistentOptions{})
+			table.Insert("foo-1", 1)
+			table.Insert("foo-2", 2)
+			table.Insert("foo-3", 3)
+			table.Close()
+
+			logPath := filepath.Join(dir, walFileName)
+			info, _ := os.Stat(logPath)
+			if err := os.Truncate(logPath, info.Size()-cut); err != nil {
+				t.Fatalf("Truncate returned error: %v", err)
+			}
+
+			reopened := openPersistentTable(t, dir, PersistentOptions{})
+			assertPersistentContents(t, reopened, map[string]int{"foo-1": 1, "foo-2": 2})
+
+			// New records must be appended after the last valid record.
+			reopened.Insert("foo-4", 4)
+			reopened.Close()
+			again := openPersistentTable(t, dir, PersistentOptions{})
+			defer again.Close()
+			assertPersistentContents(t, again, map[string]int{"foo-1": 1, "foo-2": 2, "foo-4": 4})
+		})
+	}
+}
+
+func TestPersistentRecoversFromCorruptedRecord(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{})
+	table.Insert("foo-1", 1)
+	table.Insert("foo-2", 2)
+	table.Close()
+
+	logPath := filepath.Join(dir, walFileName)
+	log, _ := os.ReadFile(logPath)
+	log[len(log)-1] ^= 0xff
+	os.WriteFile(logPath, log, 0o644)
+
+	reopened := openPersistentTable(t, dir, PersistentOptions{})
+	defer reopened.Close()
+	assertPersistentContents(t, reopened, map[string]int{"foo-1": 1})
+}
+
+func TestPersistentRejectsCorruptedMiddleRecord(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{})
+	table.Insert("foo-1", 1)
+	table.Insert("foo-2", 2)
+	table.Insert("foo-3", 3)
+	table.Close()
+
+	logPath := filepath.Join(dir, walFileName)
+	log, _ := os.ReadFile(logPath)
+	_, first, err := readWALRecord(bytes.NewReader(log))
+	if err != nil {
+		t.Fatalf("readWALRecord returned error: %v", err)
+	}
+	// Flip the last payload byte of the second record.
+	_, second, _ := readWALRecord(bytes.NewReader(log[first:]))
+	log[first+second-1] ^= 0xff
+	os.WriteFile(logPath, log, 0o644)
+
+	if reopened, err := OpenPersistent[int](dir, PersistentOptions{}); err == nil {
+		reopened.Close()
+		t.Fatalf("OpenPersistent with a corrupted middle record = nil error, expected an error")
+	} else if !errors.Is(err, errWALCorrupt) {
+		t.Errorf("OpenPersistent() = %v, expected a corrupted record error", err)
+	}
+	if after, _ := os.ReadFile(logPath); !bytes.Equal(after, log) {
+		t.Errorf("log has %d bytes after the failed open, expected it to be left unchanged at %d", len(after), len(log))
+	}
+}
+
+type failingReader struct{ err error }
+
+func (r failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestPersistentReplayReturnsReadErrors(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{})
+	table.Insert("foo-1", 1)
+	table.Insert("foo-2", 2)
+	table.Close()
+	log, _ := os.ReadFile(filepath.Join(dir, walFileName))
+
+	// A read error that is not the end of the log must not be taken for a
+	// torn tail, which would truncate acknowledged records.
+	eio := errors.New("input/output error")
+	p := &PersistentHashTable[int]{table: New[int](0)}
+	_, err := p.replayRecords(io.MultiReader(bytes.NewReader(log[:len(log)-3]), failingReader{eio}), int64(len(log)))
+	if !errors.Is(err, eio) {
+		t.Errorf("replayRecords() = %v, expected the read error", err)
+	}
+
+	p = &PersistentHashTable[int]{table: New[int](0)}
+	offset, err := p.replayRecords(bytes.NewReader(log[:len(log)-3]), int64(len(log)-3))
+	if err != nil || p.table.Len() != 1 || offset >= int64(len(log)) {
+		t.Errorf("replayRecords() of a torn log = %d, %v with %d records, expected the first record only", offset, err, p.table.Len())
+	}
+}
+
+func TestPersistentFailedWriteStopsFurtherWrites(t *testing.T) {
+	dir := t.TempDir()
+	table := openPersistentTable(t, dir, PersistentOptions{Sync: SyncNever})
+	table.Insert("foo-1", 1)
+
+	// A read-only descriptor fails the write and the truncation after it,
+	// leaving a log that can't take more records.
+	table.log.Close()
+	readOnly, err := os.Open(filepath.Join(dir, walFileName))
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	readOnly.Seek(0, io.SeekEnd)
+	table.log = readOnly
+
+	if err := table.Insert("foo-2", 2); err == nil {
+		t.Fatalf("Insert(foo-2) = nil, expected the write error")
+	}
+	if table.failed == nil {
+		t.Fatalf("failed = nil, expected the table to refuse further writes")
+	}
+	if err := table.Insert("foo-3", 3); err == nil {
+		t.Errorf("Insert(foo-3) after a torn write = nil, expected an error")
+	}
+	if _, err := table.Search("foo-2"); err == nil {
+		t.Errorf("Search(foo-2) found a key whose write failed")
+	}
+	table.Close()
+}
+
+func TestPersistentFailedSyncIsNotApplied(t *testing.T) {
+	table := openPersistentTable(t, t.TempDir(), PersistentOptions{Sync: SyncAlways})
+	defer table.Close()
+	table.Insert("foo-1", 1)
+
+	// Writes to /dev/null succeed while its sync and truncation fail.
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skipf("opening %s: %v", os.DevNull, err)
+	}
+	log := table.log
+	table.log = devNull
+	defer func() {
+		devNull.Close()
+		table.log = log
+	}()
+
+	if err := table.Insert("foo-2", 2); err == nil {
+		t.Fatalf("Insert(foo-2) = nil, expected the sync error")
+	}
+	if _, err := table.Search("foo-2"); err == nil {
+		t.Errorf("Search(foo-2) found a key whose sync failed")
+	}
+	// The record can't be cut from /dev/null, so the table must stop
+	// taking writes rather than leave it to be replayed.
+	if table.failed == nil || table.logRecords != 1 {
+		t.Errorf("failed = %v, logRecords = %d, expected the unsynced record to fail the log", table.failed, table.logRecords)
+	}
+	if err := table.Delete("foo-1"); err == nil {
+		t.Errorf("Delete(foo-1) after a record that could not be removed = nil, expected an error")
+	}
+	if _, err := table.Search("foo-1"); err != nil {
+		t.Errorf("Search(foo-1) = %v, expected the failed Delete not to be applied", err)
+	}
+}
+
+func TestPersistentDeleteRejectsLongKey(t *testing.T) {
+	table := openPersistentTable(t, t.TempDir(), PersistentOptions{})
+	defer table.Close()
+	if err := table.Delete(strings.Repeat("k", maxKeyLength+1)); err == nil {
+		t.Errorf("Delete of a too long key = nil, expected an error")
+	}
+}
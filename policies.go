@@ -0,0 +1,306 @@
+package main
+
+// contains reports whether key is in an OrderedHashTable used as a queue.
+func contains(queue *OrderedHashTable[struct{}], key string) bool {
+	_, err := queue.Search(key)
+	return err == nil
+}
+
+// popOldest removes and returns the oldest key of a queue.
+func popOldest(queue *OrderedHashTable[struct{}]) string {
+	key, _, _ := queue.Oldest()
+	queue.Delete(key)
+	return key
+}
+
+type lruPolicy struct {
+	order    *OrderedHashTable[struct{}]
+	capacity int
+}
+
+func newLRUPolicy(capacity int) *lruPolicy {
+	return &lruPolicy{order: NewOrdered[struct{}](lengthFor(capacity)), capacity: capacity}
+}
+
+func (p *lruPolicy) hit(key string) {
+	p.order.MoveToBack(key)
+}
+
+func (p *lruPolicy) add(key string) (string, bool) {
+	p.order.Insert(key, struct{}{})
+	if p.order.Len() <= p.capacity {
+		return "", false
+	}
+	return popOldest(p.order), true
+}
+
+func (p *lruPolicy) remove(key string) {
+	p.order.Delete(key)
+}
+
+// clockPolicy keeps the keys in a ring of capacity positions. A hit sets the
+// reference bit of a key; the clock hand clears set bits as it sweeps and
+// evicts the first key whose bit is clear.
+type clockPolicy struct {
+	keys       []string
+	referenced []bool
+	positions  *HashTable[int]
+	// Positions freed by remove, filled before the hand evicts anything.
+	free     []int
+	hand     int
+	capacity int
+}
+
+func newClockPolicy(capacity int) *clockPolicy {
+	return &clockPolicy{
+		positions: New[int](lengthFor(capacity)),
+		capacity:  capacity,
+	}
+}
+
+func (p *clockPolicy) hit(key string) {
+	if i, err := p.positions.Search(key); err == nil {
+		p.referenced[i] = true
+	}
+}
+
+func (p *clockPolicy) add(key string) (string, bool) {
+	if n := len(p.free); n > 0 {
+		i := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.keys[i] = key
+		p.positions.Insert(key, i)
+		return "", false
+	}
+	if len(p.keys) < p.capacity {
+		p.keys = append(p.keys, key)
+		p.referenced = append(p.referenced, false)
+		p.positions.Insert(key, len(p.keys)-1)
+		return "", false
+	}
+
+	for p.referenced[p.hand] {
+		p.referenced[p.hand] = false
+		p.hand = (p.hand + 1) % p.capacity
+	}
+	victim := p.keys[p.hand]
+	p.positions.Delete(victim)
+	p.keys[p.hand] = key
+	p.positions.Insert(key, p.hand)
+	p.hand = (p.hand + 1) % p.capacity
+	return victim, true
+}
+
+func (p *clockPolicy) remove(key string) {
+	i, err := p.positions.Search(key)
+	if err != nil {
+		return
+	}
+	p.positions.Delete(key)
+	p.keys[i] = ""
+	p.referenced[i] = false
+	p.free = append(p.free, i)
+}
+
+// twoQueuePolicy is the full 2Q algorithm. New keys enter the FIFO queue in,
+// keys evicted from in are remembered in the ghost queue out, and only keys
+// added again while in out go to the LRU queue main. A scan of keys used
+// once therefore only flushes in.
+type twoQueuePolicy struct {
+	in, out, main *OrderedHashTable[struct{}]
+	inCapacity    int
+	outCapacity   int
+	capacity      int
+}
+
+func newTwoQueuePolicy(capacity int) *twoQueuePolicy {
+	// The queue sizes recommended by the 2Q paper.
+	return &twoQueuePolicy{
+		in:          NewOrdered[struct{}](0),
+		out:         NewOrdered[struct{}](0),
+		main:        NewOrdered[struct{}](0),
+		inCapacity:  max(1, capacity/4),
+		outCapacity: max(1, capacity/2),
+		capacity:    capacity,
+	}
+}
+
+func (p *twoQueuePolicy) hit(key string) {
+	// Hits in in are not promoted, they are likely correlated references.
+	p.main.MoveToBack(key)
+}
+
+func (p *twoQueuePolicy) add(key string) (string, bool) {
+	if contains(p.out, key) {
+		p.out.Delete(key)
+		p.main.Insert(key, struct{}{})
+	} else {
+		p.in.Insert(key, struct{}{})
+	}
+	if p.in.Len()+p.main.Len() <= p.capacity {
+		return "", false
+	}
+
+	if p.in.Len() > p.inCapacity || p.main.Len() == 0 {
+		victim := popOldest(p.in)
+		p.out.Insert(victim, struct{}{})
+		if p.out.Len() > p.outCapacity {
+			popOldest(p.out)
+		}
+		return victim, true
+	}
+	return popOldest(p.main), true
+}
+
+func (p *twoQueuePolicy) remove(key string) {
+	p.in.Delete(key)
+	p.main.Delete(key)
+}
+
+// tinyLFUPolicy is W-TinyLFU. New keys enter an LRU window of about 1% of the
+// capacity. A key leaving the window competes with the oldest key of the
+// probation segment of the main area and is only admitted if the sketch
+// estimates it to be used more often. Keys hit in probation move to the
+// protected segment, which holds up to 80% of the main area.
+type tinyLFUPolicy struct {
+	sketch            *countMinSketch
+	window            *OrderedHashTable[struct{}]
+	probation         *OrderedHashTable[struct{}]
+	protected         *OrderedHashTable[struct{}]
+	windowCapacity    int
+	mainCapacity      int
+	protectedCapacity int
+}
+
+func newTinyLFUPolicy(capacity int) *tinyLFUPolicy {
+	windowCapacity := max(1, capacity/100)
+	mainCapacity := capacity - windowCapacity
+	return &tinyLFUPolicy{
+		sketch:            newCountMinSketch(capacity),
+		window:            NewOrdered[struct{}](0),
+		probation:         NewOrdered[struct{}](0),
+		protected:         NewOrdered[struct{}](0),
+		windowCapacity:    windowCapacity,
+		mainCapacity:      mainCapacity,
+		protectedCapacity: mainCapacity * 8 / 10,
+	}
+}
+
+func (p *tinyLFUPolicy) hit(key string) {
+	p.sketch.increment(key)
+	switch {
+	case contains(p.window, key):
+		p.window.MoveToBack(key)
+	case contains(p.probation, key):
+		p.probation.Delete(key)
+		p.protected.Insert(key, struct{}{})
+		if p.protected.Len() > p.protectedCapacity {
+			p.probation.Insert(popOldest(p.protected), struct{}{})
+		}
+	default:
+		p.protected.MoveToBack(key)
+	}
+}
+
+func (p *tinyLFUPolicy) add(key string) (string, bool) {
+	p.sketch.increment(key)
+	p.window.Insert(key, struct{}{})
+	if p.window.Len() <= p.windowCapacity {
+		return "", false
+	}
+
+	candidate := popOldest(p.window)
+	if p.probation.Len()+p.protected.Len() < p.mainCapacity {
+		p.probation.Insert(candidate, struct{}{})
+		return "", false
+	}
+	if p.mainCapacity == 0 {
+		return candidate, true
+	}
+
+	queue := p.probation
+	if queue.Len() == 0 {
+		queue = p.protected
+	}
+	victim, _, _ := queue.Oldest()
+	if p.sketch.estimate(candidate) <= p.sketch.estimate(victim) {
+		return candidate, true
+	}
+	queue.Delete(victim)
+	p.probation.Insert(candidate, struct{}{})
+	return victim, true
+}
+
+func (p *tinyLFUPolicy) remove(key string) {
+	p.window.Delete(key)
+	p.probation.Delete(key)
+	p.protected.Delete(key)
+}
+
+const (
+	sketchRows = 4
+	// Counters saturate at this value, like the 4 bit counters of TinyLFU.
+	sketchMaxCount = 15
+)
+
+// countMinSketch estimates how often keys were seen. Each key maps to one
+// counter per row and the smallest of them is its estimate. All counters are
+// halved after every sampleSize increments, so the estimates follow changes
+// in popularity.
+type countMinSketch struct {
+	counters   []uint8
+	width      uint64
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	// Four counters per row and cached key keep the estimates of the keys
+	// competing for admission apart.
+	width := uint64(16)
+	for width < 4*uint64(capacity) {
+		width *= 2
+	}
+	return &countMinSketch{
+		counters:   make([]uint8, sketchRows*width),
+		width:      width,
+		sampleSize: 10 * max(capacity, 16),
+	}
+}
+
+// index returns the counter of key in row. The rows use the double hashing
+// scheme of the table with the two halves of the FNV-1a hash.
+func (s *countMinSketch) index(hash uint64, row int) uint64 {
+	h1 := hash
+	h2 := hash>>32 | 1
+	return uint64(row)*s.width + (h1+uint64(row)*h2)%s.width
+}
+
+func (s *countMinSketch) increment(key string) {
+	hash := fnvHash(key)
+	for row := 0; row < sketchRows; row++ {
+		if i := s.index(hash, row); s.counters[i] < sketchMaxCount {
+			s.counters[i]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(key string) uint8 {
+	hash := fnvHash(key)
+	estimate := uint8(sketchMaxCount)
+	for row := 0; row < sketchRows; row++ {
+		estimate = min(estimate, s.counters[s.index(hash, row)])
+	}
+	return estimate
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.counters {
+		s.counters[i] /= 2
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,53 @@
+package main
+
+// slotProber gives probeSlots access to the slots of a table, whatever their
+// layout.
+type slotProber interface {
+	// slotState returns the state of the slot at index.
+	slotState(index uint64) uint8
+	// slotMatches reports whether the occupied slot at index holds key.
+	slotMatches(index uint64, key nodeKey) bool
+}
+
+// probeSlots walks the probe sequence of key over the length slots of table.
+// It returns the slot holding key and true, or otherwise the slot a new key
+// goes to and false: the first tombstone of the sequence if there is one,
+// else the empty slot that ended it. If the sequence has neither, it returns
+// length.
+func probeSlots[T slotProber](table T, length uint64, key nodeKey) (uint64, bool) {
+	location, _, found := probeSlotsCounted(table, length, key)
+	return location, found
+}
+
+// probeSlotsCounted is probeSlots that also returns the number of collisions
+// on the way, the probe length Stats records.
+func probeSlotsCounted[T slotProber](table T, length uint64, key nodeKey) (uint64, uint64, bool) {
+	var collisionCount uint64 = 0
+	homeLocation := probeIndex(key.hash, length, collisionCount)
+	firstTombstone := length
+	location := homeLocation
+
+	for {
+		switch table.slotState(location) {
+		case slotEmpty:
+			if firstTombstone < length {
+				return firstTombstone, collisionCount, false
+			}
+			return location, collisionCount, false
+		case slotTombstone:
+			if firstTombstone == length {
+				firstTombstone = location
+			}
+		default:
+			if table.slotMatches(location, key) {
+				return location, collisionCount, true
+			}
+		}
+
+		collisionCount++
+		location = probeIndex(key.hash, length, collisionCount)
+		if location == homeLocation {
+			return firstTombstone, collisionCount, false
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// setTarget runs a HashSet against the benchTarget interface. It stores no
+// values, so only the presence of keys is compared.
+type setTarget struct {
+	set *HashSet[string]
+}
+
+func (t setTarget) insert(key string, value int) { t.set.Add(key) }
+func (t setTarget) search(key string) bool       { return t.set.Has(key) }
+func (t setTarget) delete(key string) bool       { return t.set.Remove(key) }
+
+// upsertTarget runs a HashTable through lookup and upsert, which probe
+// with probeSlotsCounted instead of the loops of Search and Insert.
+type upsertTarget struct {
+	table *HashTable[int]
+}
+
+func (t upsertTarget) insert(key string, value int) { *t.table.upsert(key) = value }
+func (t upsertTarget) search(key string) bool       { return t.table.lookup(key) != nil }
+func (t upsertTarget) delete(key string) bool       { return t.table.Delete(key) == nil }
+
+func (t upsertTarget) get(key string) (int, bool) {
+	if value := t.table.lookup(key); value != nil {
+		return *value, true
+	}
+	return 0, false
+}
+
+// probedTable is a table with one of the slot layouts that share
+// probeSlots.
+type probedTable struct {
+	benchTarget
+	// get returns the value of key; it is nil for layouts without values.
+	get func(key string) (int, bool)
+	// occupied returns the number of slots that are not empty.
+	occupied func() uint64
+	len      func() int
+}
+
+var probedTables = []struct {
+	name string
+	new  func(length uint64) probedTable
+}{
+	{"hashtable", func(length uint64) probedTable {
+		table := New[int](length)
+		return probedTable{hashTableTarget{table}, searchFunc(table.Search), func() uint64 { return table.occupiedSlotCounter }, table.Len}
+	}},
+	{"upsert", func(length uint64) probedTable {
+		table := New[int](length)
+		target := upsertTarget{table}
+		return probedTable{target, target.get, func() uint64 { return table.occupiedSlotCounter }, table.Len}
+	}},
+	{"columnar", func(length uint64) probedTable {
+		table := NewColumnar[int](length)
+		return probedTable{columnarTarget{table}, searchFunc(table.Search), func() uint64 { return table.occupiedSlotCounter }, table.Len}
+	}},
+	{"inline", func(length uint64) probedTable {
+		table := NewInlineKey[int](length)
+		return probedTable{inlineKeyTarget{table}, searchFunc(table.Search), func() uint64 { return table.occupiedSlotCounter }, table.Len}
+	}},
+	{"arena", func(length uint64) probedTable {
+		table, _ := NewArena[int](length)
+		return probedTable{arenaTarget{table}, searchFunc(table.Search), func() uint64 { return table.occupiedSlotCounter }, table.Len}
+	}},
+	{"set", func(length uint64) probedTable {
+		set := NewSet[string](length)
+		return probedTable{setTarget{set}, nil, func() uint64 { return set.occupiedSlotCounter }, set.Len}
+	}},
+}
+
+func searchFunc(search func(key string) (int, error)) func(key string) (int, bool) {
+	return func(key string) (int, bool) {
+		value, err := search(key)
+		return value, err == nil
+	}
+}
+
+// TestSlotLayoutsMatchMap runs the same random operations against every slot
+// layout and a Go map and compares the results.
+func TestSlotLayoutsMatchMap(t *testing.T) {
+	for _, tc := range probedTables {
+		t.Run(tc.name, func(t *testing.T) {
+			table := tc.new(10)
+			reference := make(map[string]int)
+			r := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 20_000; i++ {
+				// Lengths up to maxKeyLength cover inline and spilled keys.
+				key := fmt.Sprintf("%0*d", 1+r.Intn(maxKeyLength), r.Intn(2000))
+				switch r.Intn(3) {
+				case 0:
+					table.insert(key, i)
+					reference[key] = i
+				case 1:
+					expected, ok := reference[key]
+					if found := table.search(key); found != ok {
+						t.Fatalf("search(%s) = %v, expected: %v", key, found, ok)
+					}
+					if table.get == nil {
+						continue
+					}
+					if value, found := table.get(key); found != ok || value != expected {
+						t.Fatalf("get(%s) = %d, %v, expected: %d, %v", key, value, found, expected, ok)
+					}
+				case 2:
+					_, ok := reference[key]
+					if deleted := table.delete(key); deleted != ok {
+						t.Fatalf("delete(%s) = %v, expected: %v", key, deleted, ok)
+					}
+					delete(reference, key)
+				}
+			}
+			for key := range reference {
+				if !table.search(key) {
+					t.Errorf("search(%s) = false at the end, expected the key to be present", key)
+				}
+			}
+			if table.len() != len(reference) {
+				t.Errorf("Len() = %d, expected: %d", table.len(), len(reference))
+			}
+		})
+	}
+}
+
+func TestSlotLayoutsUpdateInPlace(t *testing.T) {
+	for _, tc := range probedTables {
+		t.Run(tc.name, func(t *testing.T) {
+			table := tc.new(10)
+			key := "foo-1"
+			if table.search(key) {
+				t.Errorf("search(%s) on an empty table = true, expected: false", key)
+			}
+			table.insert(key, 100)
+			table.insert(key, 200)
+			if !table.search(key) || table.len() != 1 || table.occupied() != 1 {
+				t.Errorf("len() = %d, occupied slots = %d after updating %s, expected one key in one slot", table.len(), table.occupied(), key)
+			}
+			if table.get != nil {
+				if value, found := table.get(key); !found || value != 200 {
+					t.Errorf("get(%s) = %d, %v, expected: 200", key, value, found)
+				}
+			}
+			if !table.delete(key) || table.delete(key) {
+				t.Errorf("delete(%s) twice, expected only the first delete to find the key", key)
+			}
+		})
+	}
+}
+
+func TestSlotLayoutsProbingAndTombstoneReuse(t *testing.T) {
+	var length uint64 = 389
+	keys := findCollidingKeys(6, length)
+	for _, tc := range probedTables {
+		t.Run(tc.name, func(t *testing.T) {
+			table := tc.new(length)
+			for i, key := range keys {
+				table.insert(key, i)
+			}
+
+			table.delete(keys[2])
+			for i, key := range keys {
+				if found := table.search(key); found != (i != 2) {
+					t.Errorf("search(%s) = %v, expected: %v", key, found, i != 2)
+				}
+			}
+
+			table.insert(keys[2], 20)
+			if occupied := table.occupied(); occupied != uint64(len(keys)) {
+				t.Errorf("occupied slots = %d, expected the tombstone to be reused: %d", occupied, len(keys))
+			}
+			if table.get != nil {
+				if value, _ := table.get(keys[2]); value != 20 {
+					t.Errorf("get(%s) = %d, expected: 20", keys[2], value)
+				}
+			}
+		})
+	}
+}
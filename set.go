@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"iter"
+)
+
+// setSlot is the slot of a HashSet. Unlike data it has no value field.
+type setSlot struct {
+	key   nodeKey
+	state uint8
+}
+
+// HashSet is a set of string keys using the same double hashing, tombstones
+// and resize thresholds as HashTable.
+type HashSet[K ~string] struct {
+	length              uint64
+	slots               []setSlot
+	activeSlotCounter   uint64
+	occupiedSlotCounter uint64
+}
+
+func NewSet[K ~string](length uint64) *HashSet[K] {
+	primeLength := pickLargestLength(length)
+	return &HashSet[K]{
+		length: primeLength,
+		slots:  make([]setSlot, primeLength),
+	}
+}
+
+// SetOf returns a set holding the given keys.
+func SetOf[K ~string](keys ...K) *HashSet[K] {
+	s := NewSet[K](lengthFor(len(keys)))
+	for _, key := range keys {
+		s.Add(key)
+	}
+	return s
+}
+
+func (s *HashSet[K]) Len() int {
+	return int(s.activeSlotCounter)
+}
+
+func (s *HashSet[K]) computeLoadFactor() float32 {
+	return float32(s.occupiedSlotCounter) / float32(s.length)
+}
+
+func (s *HashSet[K]) resize(newSize uint64) {
+	oldSlots := s.slots
+	s.length = newSize
+	s.slots = make([]setSlot, newSize)
+	s.activeSlotCounter = 0
+	s.occupiedSlotCounter = 0
+	for i := range oldSlots {
+		if oldSlots[i].state == slotOccupied {
+			s.add(oldSlots[i].key)
+		}
+	}
+}
+
+func (s *HashSet[K]) addItem(index uint64, key nodeKey) {
+	if s.slots[index].state == slotEmpty {
+		s.occupiedSlotCounter++
+	}
+	s.slots[index] = setSlot{key: key, state: slotOccupied}
+	s.activeSlotCounter++
+}
+
+func (s *HashSet[K]) slotState(index uint64) uint8 {
+	return s.slots[index].state
+}
+
+func (s *HashSet[K]) slotMatches(index uint64, key nodeKey) bool {
+	item := &s.slots[index]
+	return item.key.hash == key.hash && item.key.value == key.value
+}
+
+// add inserts key unless it is already in the set and reports whether it was
+// inserted.
+func (s *HashSet[K]) add(key nodeKey) bool {
+	location, found := probeSlots(s, s.length, key)
+	if found || location == s.length {
+		return false
+	}
+	s.addItem(location, key)
+	return true
+}
+
+// Add inserts key into the set and reports whether it was not in the set yet.
+func (s *HashSet[K]) Add(key K) bool {
+	if s.computeLoadFactor() >= risizeUpThreshold {
+		s.resize(nextSizeUp(s.length))
+	}
+	return s.add(NewKey(string(key)))
+}
+
+func (s *HashSet[K]) find(key K) (*setSlot, bool) {
+	location, found := probeSlots(s, s.length, NewKey(string(key)))
+	if !found {
+		return nil, false
+	}
+	return &s.slots[location], true
+}
+
+func (s *HashSet[K]) Has(key K) bool {
+	_, ok := s.find(key)
+	return ok
+}
+
+// Remove deletes key from the set and reports whether it was in the set.
+func (s *HashSet[K]) Remove(key K) bool {
+	item, ok := s.find(key)
+	if !ok {
+		return false
+	}
+	item.state = slotTombstone
+	s.activeSlotCounter--
+
+	if s.computeLoadFactor() <= resizeDownThreshold && s.length > primes[0] {
+		s.resize(nextSizeDown(s.length))
+	}
+	return true
+}
+
+// All returns an iterator over the keys of the set in slot order, which
+// changes on every resize. The set must not be modified while iterating.
+func (s *HashSet[K]) All() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for i := range s.slots {
+			item := &s.slots[i]
+			if item.state != slotOccupied {
+				continue
+			}
+			if !yield(K(item.key.value)) {
+				return
+			}
+		}
+	}
+}
+
+// smallerSetFirst returns the set with fewer keys first.
+func smallerSetFirst[K ~string](a, b *HashSet[K]) (*HashSet[K], *HashSet[K]) {
+	if a.Len() <= b.Len() {
+		return a, b
+	}
+	return b, a
+}
+
+// Union returns a new set with the keys of both s and other.
+func (s *HashSet[K]) Union(other *HashSet[K]) *HashSet[K] {
+	result := NewSet[K](lengthFor(s.Len() + other.Len()))
+	for key := range s.All() {
+		result.Add(key)
+	}
+	for key := range other.All() {
+		result.Add(key)
+	}
+	return result
+}
+
+// Intersection returns a new set with the keys present in both s and other.
+func (s *HashSet[K]) Intersection(other *HashSet[K]) *HashSet[K] {
+	smaller, larger := smallerSetFirst(s, other)
+	result := NewSet[K](lengthFor(smaller.Len()))
+	for key := range smaller.All() {
+		if larger.Has(key) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
+// Difference returns a new set with the keys of s that are not in other.
+func (s *HashSet[K]) Difference(other *HashSet[K]) *HashSet[K] {
+	result := NewSet[K](lengthFor(s.Len()))
+	for key := range s.All() {
+		if !other.Has(key) {
+			result.Add(key)
+		}
+	}
+	return result
+}
+
+// IsSubset reports whether every key of s is also in other.
+func (s *HashSet[K]) IsSubset(other *HashSet[K]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for key := range s.All() {
+		if !other.Has(key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *HashSet[K]) keys() []string {
+	keys := make([]string, 0, s.activeSlotCounter)
+	for key := range s.All() {
+		keys = append(keys, string(key))
+	}
+	return keys
+}
+
+// MarshalJSON encodes the set as a JSON array of its keys in slot order.
+func (s *HashSet[K]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.keys())
+}
+
+// UnmarshalJSON adds the keys of a JSON array to the set. Like ReadJSON for
+// tables, keys already in the set are kept.
+func (s *HashSet[K]) UnmarshalJSON(b []byte) error {
+	var keys []string
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return err
+	}
+	if err := validateKeys(keys); err != nil {
+		return err
+	}
+	if s.length == 0 {
+		*s = *NewSet[K](lengthFor(len(keys)))
+	}
+	for _, key := range keys {
+		s.Add(K(key))
+	}
+	return nil
+}
+
+func (s *HashSet[K]) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s.keys()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode replaces the contents of the set with the decoded keys.
+func (s *HashSet[K]) GobDecode(b []byte) error {
+	var keys []string
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&keys); err != nil {
+		return err
+	}
+	if err := validateKeys(keys); err != nil {
+		return err
+	}
+	*s = *NewSet[K](lengthFor(len(keys)))
+	for _, key := range keys {
+		s.Add(K(key))
+	}
+	return nil
+}
+
+func validateKeys(keys []string) error {
+	for _, key := range keys {
+		if err := validateKey(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
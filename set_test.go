@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type setKey string
+
+func sortedKeys[K ~string](s *HashSet[K]) []K {
+	return slices.Sorted(s.All())
+}
+
+func TestSetAddHasRemove(t *testing.T) {
+	set := NewSet[string](10)
+	if !set.Add("foo-1") {
+		t.Errorf("Add(foo-1) = false, expected a new key")
+	}
+	if set.Add("foo-1") {
+		t.Errorf("Add(foo-1) twice = true, expected the key to be present")
+	}
+	if !set.Has("foo-1") || set.Has("foo-2") {
+		t.Errorf("Has(foo-1), Has(foo-2) = %v, %v, expected: true, false", set.Has("foo-1"), set.Has("foo-2"))
+	}
+	if !set.Remove("foo-1") || set.Remove("foo-1") {
+		t.Errorf("Remove(foo-1) expected to succeed exactly once")
+	}
+	if set.Len() != 0 {
+		t.Errorf("Len() = %d, expected: 0", set.Len())
+	}
+}
+
+func TestSetResize(t *testing.T) {
+	set := NewSet[setKey](10)
+	for i := 0; i < 1000; i++ {
+		set.Add(setKey(fmt.Sprintf("foo-%d", i)))
+	}
+	for i := 0; i < 990; i++ {
+		set.Remove(setKey(fmt.Sprintf("foo-%d", i)))
+	}
+	for i := 0; i < 1000; i++ {
+		key := setKey(fmt.Sprintf("foo-%d", i))
+		if set.Has(key) != (i >= 990) {
+			t.Errorf("Has(%s) = %v, expected: %v", key, set.Has(key), i >= 990)
+		}
+	}
+	if set.Len() != 10 {
+		t.Errorf("Len() = %d, expected: 10", set.Len())
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := SetOf[setKey]("a", "b", "c")
+	b := SetOf[setKey]("b", "c", "d", "e")
+
+	tests := []struct {
+		name     string
+		set      *HashSet[setKey]
+		expected []setKey
+	}{
+		{"Union", a.Union(b), []setKey{"a", "b", "c", "d", "e"}},
+		{"Intersection", a.Intersection(b), []setKey{"b", "c"}},
+		{"Difference", a.Difference(b), []setKey{"a"}},
+		{"reverse Difference", b.Difference(a), []setKey{"d", "e"}},
+	}
+	for _, test := range tests {
+		if got := sortedKeys(test.set); !slices.Equal(got, test.expected) {
+			t.Errorf("%s = %v, expected: %v", test.name, got, test.expected)
+		}
+	}
+
+	if a.IsSubset(b) {
+		t.Errorf("IsSubset = true, expected false for %v and %v", sortedKeys(a), sortedKeys(b))
+	}
+	if !SetOf[setKey]("b", "c").IsSubset(b) || !NewSet[setKey](0).IsSubset(a) {
+		t.Errorf("IsSubset = false, expected true for subsets")
+	}
+}
+
+func TestSetSlotHasNoValue(t *testing.T) {
+	skipUnless64Bit(t)
+	if size := layoutOf(reflect.TypeOf(setSlot{})).Size; size != 32 {
+		t.Errorf("setSlot size = %d, expected: 32", size)
+	}
+}
+
+func TestSetJSON(t *testing.T) {
+	set := SetOf("foo-1", "foo-2")
+	encoded, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	var keys []string
+	if err := json.Unmarshal(encoded, &keys); err != nil {
+		t.Fatalf("set encodes as %s, expected a JSON array: %v", encoded, err)
+	}
+
+	var decoded HashSet[string]
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if got := sortedKeys(&decoded); !slices.Equal(got, []string{"foo-1", "foo-2"}) {
+		t.Errorf("decoded set = %v, expected: [foo-1 foo-2]", got)
+	}
+
+	tooLong := fmt.Sprintf(`["%0*d"]`, maxKeyLength+1, 1)
+	if err := json.Unmarshal([]byte(tooLong), &decoded); err == nil {
+		t.Errorf("json.Unmarshal(%s) = nil error, expected the key to be rejected", tooLong)
+	}
+}
+
+func TestSetGob(t *testing.T) {
+	set := SetOf("foo-1", "foo-2", "foo-3")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(set); err != nil {
+		t.Fatalf("gob encoding returned error: %v", err)
+	}
+
+	decoded := SetOf("stale")
+	if err := gob.NewDecoder(&buf).Decode(decoded); err != nil {
+		t.Fatalf("gob decoding returned error: %v", err)
+	}
+	if got, expected := sortedKeys(decoded), sortedKeys(set); !slices.Equal(got, expected) {
+		t.Errorf("decoded set = %v, expected the stale key to be replaced: %v", got, expected)
+	}
+}
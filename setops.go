@@ -0,0 +1,85 @@
+package main
+
+// Merge inserts every key-value pair of other into h. For a key present in
+// both tables, conflict is called with the key, the value in h and the value
+// in other and its result is stored. A nil conflict keeps the value of other.
+// Merging a table into itself does nothing.
+func (h *HashTable[V]) Merge(other *HashTable[V], conflict func(key string, current V, incoming V) V) {
+	// Inserting into the table being iterated could resize it mid-iteration.
+	if other == h {
+		return
+	}
+	for key, incoming := range other.All() {
+		if conflict != nil {
+			if current, err := h.Search(key); err == nil {
+				incoming = conflict(key, current, incoming)
+			}
+		}
+		h.Insert(key, incoming)
+	}
+}
+
+// smallerFirst returns the table with fewer keys first.
+func smallerFirst[V any](a, b *HashTable[V]) (*HashTable[V], *HashTable[V]) {
+	if a.Len() <= b.Len() {
+		return a, b
+	}
+	return b, a
+}
+
+// Intersect returns a new table with the keys present in both h and other,
+// with their values from h.
+func (h *HashTable[V]) Intersect(other *HashTable[V]) *HashTable[V] {
+	smaller, larger := smallerFirst(h, other)
+	result := New[V](lengthFor(smaller.Len()))
+	for key, value := range smaller.All() {
+		largerValue, err := larger.Search(key)
+		if err != nil {
+			continue
+		}
+		if larger == h {
+			value = largerValue
+		}
+		result.Insert(key, value)
+	}
+	return result
+}
+
+// Difference returns a new table with the key-value pairs of h whose key is
+// not in other.
+func (h *HashTable[V]) Difference(other *HashTable[V]) *HashTable[V] {
+	result := New[V](lengthFor(h.Len()))
+	for key, value := range h.All() {
+		if _, err := other.Search(key); err != nil {
+			result.Insert(key, value)
+		}
+	}
+	return result
+}
+
+// SymmetricDifference returns a new table with the key-value pairs whose key
+// is in exactly one of h and other.
+func (h *HashTable[V]) SymmetricDifference(other *HashTable[V]) *HashTable[V] {
+	result := h.Difference(other)
+	for key, value := range other.All() {
+		if _, err := h.Search(key); err != nil {
+			result.Insert(key, value)
+		}
+	}
+	return result
+}
+
+// Equal reports whether h and other hold the same keys with values that eq
+// considers equal.
+func (h *HashTable[V]) Equal(other *HashTable[V], eq func(a V, b V) bool) bool {
+	if h.Len() != other.Len() {
+		return false
+	}
+	for key, value := range h.All() {
+		otherValue, err := other.Search(key)
+		if err != nil || !eq(value, otherValue) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+)
+
+func tableFromMap(entries map[string]int) *HashTable[int] {
+	table := New[int](10)
+	for key, value := range entries {
+		table.Insert(key, value)
+	}
+	return table
+}
+
+func expectEntries(t *testing.T, name string, table *HashTable[int], expected map[string]int) {
+	t.Helper()
+	if got := maps.Collect(table.All()); !maps.Equal(got, expected) {
+		t.Errorf("%s = %v, expected: %v", name, got, expected)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	table := tableFromMap(map[string]int{"a": 1, "b": 2})
+	other := tableFromMap(map[string]int{"b": 20, "c": 30})
+
+	table.Merge(other, func(key string, current int, incoming int) int {
+		return current + incoming
+	})
+	expectEntries(t, "Merge with conflict function", table, map[string]int{"a": 1, "b": 22, "c": 30})
+
+	table.Merge(other, nil)
+	expectEntries(t, "Merge without conflict function", table, map[string]int{"a": 1, "b": 20, "c": 30})
+	expectEntries(t, "other after Merge", other, map[string]int{"b": 20, "c": 30})
+}
+
+func TestMergeIntoItself(t *testing.T) {
+	table := New[int](10)
+	for i := 0; i < 50; i++ {
+		table.Insert(fmt.Sprintf("key-%d", i), i)
+	}
+	expected := maps.Collect(table.All())
+
+	table.Merge(table, func(key string, current int, incoming int) int {
+		return current + incoming
+	})
+	expectEntries(t, "Merge(table, table)", table, expected)
+}
+
+func TestIntersectDifferenceSymmetricDifference(t *testing.T) {
+	small := tableFromMap(map[string]int{"a": 1, "b": 2, "c": 3})
+	large := New[int](10)
+	for i := 0; i < 100; i++ {
+		large.Insert(fmt.Sprintf("key-%d", i), i)
+	}
+	large.Insert("b", 20)
+	large.Insert("c", 30)
+
+	small.EnableLookupStats()
+	expectEntries(t, "small.Intersect(large)", small.Intersect(large), map[string]int{"b": 2, "c": 3})
+	if lookups := small.Stats().Lookups.Operations; lookups != 0 {
+		t.Errorf("small.Intersect(large) searched small %d times, expected it to use the iterated values", lookups)
+	}
+	expectEntries(t, "small.Intersect(small)", small.Intersect(small), map[string]int{"a": 1, "b": 2, "c": 3})
+	expectEntries(t, "large.Intersect(small)", large.Intersect(small), map[string]int{"b": 20, "c": 30})
+	expectEntries(t, "small.Difference(large)", small.Difference(large), map[string]int{"a": 1})
+
+	symmetric := map[string]int{"a": 1}
+	for i := 0; i < 100; i++ {
+		symmetric[fmt.Sprintf("key-%d", i)] = i
+	}
+	expectEntries(t, "small.SymmetricDifference(large)", small.SymmetricDifference(large), symmetric)
+	expectEntries(t, "small after set operations", small, map[string]int{"a": 1, "b": 2, "c": 3})
+}
+
+func TestEqual(t *testing.T) {
+	eq := func(a, b int) bool { return a == b }
+	table := tableFromMap(map[string]int{"a": 1, "b": 2})
+
+	tests := []struct {
+		other    map[string]int
+		expected bool
+	}{
+		{map[string]int{"b": 2, "a": 1}, true},
+		{map[string]int{"a": 1, "b": 3}, false},
+		{map[string]int{"a": 1, "c": 2}, false},
+		{map[string]int{"a": 1}, false},
+		{map[string]int{}, false},
+	}
+	for _, test := range tests {
+		if got := table.Equal(tableFromMap(test.other), eq); got != test.expected {
+			t.Errorf("Equal(%v) = %v, expected: %v", test.other, got, test.expected)
+		}
+	}
+
+	sameParity := func(a, b int) bool { return a%2 == b%2 }
+	if !table.Equal(tableFromMap(map[string]int{"a": 3, "b": 4}), sameParity) {
+		t.Errorf("Equal with a custom eq = false, expected the values to be compared by eq")
+	}
+}
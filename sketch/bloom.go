@@ -0,0 +1,186 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Bloom is a Bloom filter. The k bit positions of a key are derived from its
+// hash by double hashing with the two 32 bit halves, the scheme the hash
+// table uses for its probe sequence.
+type Bloom struct {
+	words []uint64
+	size  uint64
+	// mask is size-1 if size is a power of two, 0 otherwise.
+	mask       uint64
+	hashes     uint64
+	hasherName string
+	hasher     Hasher
+}
+
+// NewBloom returns a filter sized for expected keys at the given false
+// positive rate, hashing with the named hasher.
+func NewBloom(expected int, falsePositiveRate float64, hasher string) (*Bloom, error) {
+	if expected < 1 {
+		return nil, errors.New("sketch: a Bloom filter needs a positive expected count")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("sketch: false positive rate %v is not between 0 and 1", falsePositiveRate)
+	}
+	// m = -n ln p / (ln 2)^2 bits and k = m/n ln 2 hashes are optimal.
+	size := uint64(math.Ceil(-float64(expected) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Round(float64(size) / float64(expected) * math.Ln2))
+	return newBloom(size, max(hashes, 1), hasher)
+}
+
+// NewBloomBits returns a filter of at least the given number of bits that
+// sets hashes bits per key. Sizes that are a power of two of at least 64
+// bits are kept exactly and make lookups cheaper.
+func NewBloomBits(bits, hashes uint64, hasher string) (*Bloom, error) {
+	if bits == 0 || hashes == 0 {
+		return nil, fmt.Errorf("sketch: invalid Bloom filter of %d bits and %d hashes", bits, hashes)
+	}
+	return newBloom(bits, hashes, hasher)
+}
+
+func newBloom(size, hashes uint64, hasherName string) (*Bloom, error) {
+	if hasherName == "" {
+		hasherName = DefaultHasher
+	}
+	hasher, err := LookupHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+	words := (size + 63) / 64
+	size = words * 64
+	var mask uint64
+	if size&(size-1) == 0 {
+		mask = size - 1
+	}
+	return &Bloom{
+		words:      make([]uint64, words),
+		size:       size,
+		mask:       mask,
+		hashes:     hashes,
+		hasherName: hasherName,
+		hasher:     hasher,
+	}, nil
+}
+
+// Bits returns the number of bits of the filter.
+func (b *Bloom) Bits() uint64 {
+	return b.size
+}
+
+// Hashes returns the number of bits set per key.
+func (b *Bloom) Hashes() uint64 {
+	return b.hashes
+}
+
+// bit returns the i-th bit position of a key with the given hash.
+func (b *Bloom) bit(h1, h2, i uint64) uint64 {
+	if b.mask != 0 {
+		return (h1 + i*h2) & b.mask
+	}
+	return (h1 + i*h2) % b.size
+}
+
+func (b *Bloom) Add(key string) {
+	b.AddHash(b.hasher(key))
+}
+
+// AddHash adds the key with the given hash, which must come from the hasher
+// of the filter. Callers that already hashed the key use it to not hash it
+// again.
+func (b *Bloom) AddHash(hash uint64) {
+	h1, h2 := hash&math.MaxUint32, hash>>32|1
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := b.bit(h1, h2, i)
+		b.words[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Contains reports whether key may have been added. It never returns false
+// for an added key.
+func (b *Bloom) Contains(key string) bool {
+	return b.ContainsHash(b.hasher(key))
+}
+
+// ContainsHash is Contains for the key with the given hash, which must come
+// from the hasher of the filter.
+func (b *Bloom) ContainsHash(hash uint64) bool {
+	h1, h2 := hash&math.MaxUint32, hash>>32|1
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := b.bit(h1, h2, i)
+		if b.words[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// FillRatio returns the fraction of bits set.
+func (b *Bloom) FillRatio() float64 {
+	set := 0
+	for _, word := range b.words {
+		set += bits.OnesCount64(word)
+	}
+	return float64(set) / float64(b.size)
+}
+
+// FalsePositiveRate estimates the current false positive rate from the fill
+// ratio.
+func (b *Bloom) FalsePositiveRate() float64 {
+	return math.Pow(b.FillRatio(), float64(b.hashes))
+}
+
+// Merge adds the keys of other to b. Both filters must have the same size,
+// number of hashes and hasher.
+func (b *Bloom) Merge(other *Bloom) error {
+	if b.size != other.size || b.hashes != other.hashes || b.hasherName != other.hasherName {
+		return fmt.Errorf("sketch: can't merge a Bloom filter of %d bits, %d hashes and hasher %s into one of %d bits, %d hashes and hasher %s",
+			other.size, other.hashes, other.hasherName, b.size, b.hashes, b.hasherName)
+	}
+	for i, word := range other.words {
+		b.words[i] |= word
+	}
+	return nil
+}
+
+func (b *Bloom) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, kindBloom, b.hasherName)
+	data = binary.LittleEndian.AppendUint64(data, b.size)
+	data = binary.LittleEndian.AppendUint64(data, b.hashes)
+	for _, word := range b.words {
+		data = binary.LittleEndian.AppendUint64(data, word)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces b with the encoded filter.
+func (b *Bloom) UnmarshalBinary(data []byte) error {
+	d := &decoder{data: data}
+	name, _ := d.header(kindBloom)
+	size, hashes := d.uint64(), d.uint64()
+	if d.err != nil {
+		return d.err
+	}
+	if size == 0 || size%64 != 0 || size/64 > uint64(len(d.data)/8) || hashes == 0 {
+		return fmt.Errorf("sketch: invalid Bloom filter of %d bits and %d hashes", size, hashes)
+	}
+	decoded, err := newBloom(size, hashes, name)
+	if err != nil {
+		return err
+	}
+	for i := range decoded.words {
+		decoded.words[i] = d.uint64()
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	*b = *decoded
+	return nil
+}
@@ -0,0 +1,144 @@
+package sketch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	for _, rate := range []float64{0.1, 0.01, 0.001} {
+		for _, hasher := range []string{"fnv1a", "fnv1a-lib"} {
+			const expected = 50000
+			bloom, err := NewBloom(expected, rate, hasher)
+			if err != nil {
+				t.Fatalf("NewBloom(%d, %v, %s): %v", expected, rate, hasher, err)
+			}
+			for i := 0; i < expected; i++ {
+				bloom.Add(fmt.Sprintf("key-%d", i))
+			}
+			for i := 0; i < expected; i++ {
+				if key := fmt.Sprintf("key-%d", i); !bloom.Contains(key) {
+					t.Fatalf("Contains(%s) = false for an added key", key)
+				}
+			}
+
+			falsePositives := 0
+			const lookups = 200000
+			for i := 0; i < lookups; i++ {
+				if bloom.Contains(fmt.Sprintf("missing-%d", i)) {
+					falsePositives++
+				}
+			}
+			measured := float64(falsePositives) / lookups
+			if measured > 1.5*rate {
+				t.Errorf("%s at rate %v: measured false positive rate %.5f", hasher, rate, measured)
+			}
+			if estimate := bloom.FalsePositiveRate(); estimate > 1.5*rate {
+				t.Errorf("%s at rate %v: FalsePositiveRate() = %.5f", hasher, rate, estimate)
+			}
+		}
+	}
+}
+
+func TestBloomMerge(t *testing.T) {
+	a, _ := NewBloom(1000, 0.01, "")
+	b, _ := NewBloom(1000, 0.01, "")
+	a.Add("only-a")
+	b.Add("only-b")
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+	if !a.Contains("only-a") || !a.Contains("only-b") {
+		t.Errorf("merged filter lost a key")
+	}
+
+	other, _ := NewBloom(5000, 0.01, "")
+	if err := a.Merge(other); err == nil {
+		t.Errorf("Merge of a differently sized filter succeeded, expected an error")
+	}
+	lib, _ := NewBloom(1000, 0.01, "fnv1a-lib")
+	if err := a.Merge(lib); err == nil {
+		t.Errorf("Merge of a filter with another hasher succeeded, expected an error")
+	}
+}
+
+func TestBloomBinaryRoundTrip(t *testing.T) {
+	bloom, _ := NewBloom(1000, 0.01, "fnv1a-lib")
+	for i := 0; i < 1000; i++ {
+		bloom.Add(fmt.Sprintf("key-%d", i))
+	}
+	data, err := bloom.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+
+	var decoded Bloom
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if decoded.Bits() != bloom.Bits() || decoded.Hashes() != bloom.Hashes() || decoded.hasherName != "fnv1a-lib" {
+		t.Errorf("decoded %d bits, %d hashes, hasher %s, expected: %d, %d, fnv1a-lib", decoded.Bits(), decoded.Hashes(), decoded.hasherName, bloom.Bits(), bloom.Hashes())
+	}
+	for i := 0; i < 1000; i++ {
+		if !decoded.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("decoded filter lost key-%d", i)
+		}
+	}
+
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("UnmarshalBinary of truncated data succeeded, expected an error")
+	}
+	hll, _ := NewHyperLogLog(10, "")
+	hllData, _ := hll.MarshalBinary()
+	if err := decoded.UnmarshalBinary(hllData); err == nil {
+		t.Errorf("UnmarshalBinary of a HyperLogLog succeeded, expected an error")
+	}
+}
+
+func TestNewBloomInvalid(t *testing.T) {
+	if _, err := NewBloom(0, 0.01, ""); err == nil {
+		t.Errorf("NewBloom(0, ...) succeeded, expected an error")
+	}
+	if _, err := NewBloom(10, 1, ""); err == nil {
+		t.Errorf("NewBloom(10, 1, ...) succeeded, expected an error")
+	}
+	if _, err := NewBloom(10, 0.01, "missing"); err == nil {
+		t.Errorf("NewBloom with an unknown hasher succeeded, expected an error")
+	}
+	if _, err := NewBloomBits(0, 3, ""); err == nil {
+		t.Errorf("NewBloomBits(0, 3, ...) succeeded, expected an error")
+	}
+	if _, err := NewBloomBits(1024, 0, ""); err == nil {
+		t.Errorf("NewBloomBits(1024, 0, ...) succeeded, expected an error")
+	}
+}
+
+func TestBloomHashesMatchKeys(t *testing.T) {
+	// A power of two size uses a mask, any other size the remainder.
+	for _, bits := range []uint64{1 << 16, 1000 * 64} {
+		bloom, err := NewBloomBits(bits, 7, "")
+		if err != nil {
+			t.Fatalf("NewBloomBits(%d, 7, ...): %v", bits, err)
+		}
+		if bloom.Bits() != bits {
+			t.Errorf("Bits() = %d, expected: %d", bloom.Bits(), bits)
+		}
+		for i := 0; i < 1000; i++ {
+			bloom.AddHash(FNV1a(fmt.Sprintf("key-%d", i)))
+		}
+		for i := 0; i < 1000; i++ {
+			if key := fmt.Sprintf("key-%d", i); !bloom.Contains(key) {
+				t.Fatalf("Contains(%s) = false for a key added by its hash", key)
+			}
+		}
+		falsePositives := 0
+		for i := 0; i < 10000; i++ {
+			if bloom.ContainsHash(FNV1a(fmt.Sprintf("missing-%d", i))) {
+				falsePositives++
+			}
+		}
+		if falsePositives > 10 {
+			t.Errorf("%d bits: %d of 10000 missing keys found, expected at most 10", bits, falsePositives)
+		}
+	}
+}
@@ -0,0 +1,277 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Fingerprints per bucket.
+	cuckooBucketSize = 4
+	// Relocations tried before an insert gives up.
+	cuckooMaxKicks = 500
+	// Load a filter is sized for; cuckoo filters with buckets of four fill
+	// to about 95% before inserts start to fail.
+	cuckooTargetLoad = 0.9
+)
+
+var ErrFilterFull = errors.New("sketch: cuckoo filter is full")
+
+// Cuckoo is a cuckoo filter: it stores a 16 bit fingerprint of every key in
+// one of two buckets, so keys can be deleted again. The second bucket is
+// derived from the first one and the fingerprint alone (partial-key cuckoo
+// hashing), which lets fingerprints move between buckets without their key.
+type Cuckoo struct {
+	buckets    [][cuckooBucketSize]uint16
+	mask       uint64
+	count      uint64
+	hasherName string
+	hasher     Hasher
+	// victim holds the fingerprint left homeless by a failed insert. The
+	// filter still reports its key as present but accepts no more inserts.
+	victim      uint16
+	victimIndex uint64
+	// rng picks the fingerprint to relocate; a fixed seed keeps the filter
+	// contents deterministic.
+	rng uint64
+}
+
+// NewCuckoo returns a filter with room for about capacity keys, hashing with
+// the named hasher.
+func NewCuckoo(capacity int, hasher string) (*Cuckoo, error) {
+	if capacity < 1 {
+		return nil, errors.New("sketch: a cuckoo filter needs a positive capacity")
+	}
+	buckets := uint64(1)
+	for float64(buckets*cuckooBucketSize)*cuckooTargetLoad < float64(capacity) {
+		buckets *= 2
+	}
+	return newCuckoo(buckets, hasher)
+}
+
+func newCuckoo(buckets uint64, hasherName string) (*Cuckoo, error) {
+	if hasherName == "" {
+		hasherName = DefaultHasher
+	}
+	hasher, err := LookupHasher(hasherName)
+	if err != nil {
+		return nil, err
+	}
+	return &Cuckoo{
+		buckets:    make([][cuckooBucketSize]uint16, buckets),
+		mask:       buckets - 1,
+		hasherName: hasherName,
+		hasher:     hasher,
+		rng:        0x9e3779b97f4a7c15,
+	}, nil
+}
+
+// Len returns the number of stored fingerprints.
+func (c *Cuckoo) Len() int {
+	return int(c.count)
+}
+
+// Capacity returns the number of fingerprint slots.
+func (c *Cuckoo) Capacity() int {
+	return len(c.buckets) * cuckooBucketSize
+}
+
+// locate returns the fingerprint and first bucket of key. The hash is mixed
+// first so that the two don't depend on each other. Fingerprint 0 marks an
+// empty slot and is never used.
+func (c *Cuckoo) locate(key string) (uint16, uint64) {
+	hash := mix64(c.hasher(key))
+	fingerprint := uint16(hash >> 32)
+	if fingerprint == 0 {
+		fingerprint = 1
+	}
+	return fingerprint, hash & c.mask
+}
+
+// alternate returns the other bucket of a fingerprint stored in bucket i.
+// Applied twice it returns i.
+func (c *Cuckoo) alternate(i uint64, fingerprint uint16) uint64 {
+	return (i ^ mix64(uint64(fingerprint))) & c.mask
+}
+
+// Add inserts key. Adding a key twice stores two fingerprints, so it must
+// then be deleted twice. It returns ErrFilterFull if no slot could be freed.
+func (c *Cuckoo) Add(key string) error {
+	fingerprint, i := c.locate(key)
+	return c.insert(fingerprint, i)
+}
+
+func (c *Cuckoo) insert(fingerprint uint16, i uint64) error {
+	if c.victim != 0 {
+		return ErrFilterFull
+	}
+	j := c.alternate(i, fingerprint)
+	if c.place(i, fingerprint) || c.place(j, fingerprint) {
+		return nil
+	}
+
+	// Evict a random fingerprint and move it to its other bucket, until one
+	// lands in a free slot.
+	if c.next()&1 == 0 {
+		i = j
+	}
+	for kick := 0; kick < cuckooMaxKicks; kick++ {
+		slot := c.next() % cuckooBucketSize
+		fingerprint, c.buckets[i][slot] = c.buckets[i][slot], fingerprint
+		i = c.alternate(i, fingerprint)
+		if c.place(i, fingerprint) {
+			return nil
+		}
+	}
+	c.victim = fingerprint
+	c.victimIndex = i
+	c.count++
+	return ErrFilterFull
+}
+
+func (c *Cuckoo) place(i uint64, fingerprint uint16) bool {
+	bucket := &c.buckets[i]
+	for slot := range bucket {
+		if bucket[slot] == 0 {
+			bucket[slot] = fingerprint
+			c.count++
+			return true
+		}
+	}
+	return false
+}
+
+// next is a xorshift generator.
+func (c *Cuckoo) next() uint64 {
+	c.rng ^= c.rng << 13
+	c.rng ^= c.rng >> 7
+	c.rng ^= c.rng << 17
+	return c.rng
+}
+
+// Contains reports whether key may have been added. It never returns false
+// for a key that was added and not deleted.
+func (c *Cuckoo) Contains(key string) bool {
+	fingerprint, i := c.locate(key)
+	j := c.alternate(i, fingerprint)
+	if c.victim == fingerprint && (c.victimIndex == i || c.victimIndex == j) {
+		return true
+	}
+	for _, stored := range c.buckets[i] {
+		if stored == fingerprint {
+			return true
+		}
+	}
+	for _, stored := range c.buckets[j] {
+		if stored == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes one fingerprint of key and reports whether there was one.
+// Deleting a key that was never added may remove the fingerprint of another
+// key that shares it.
+func (c *Cuckoo) Delete(key string) bool {
+	fingerprint, i := c.locate(key)
+	j := c.alternate(i, fingerprint)
+	if !c.remove(i, fingerprint) && !c.remove(j, fingerprint) {
+		if c.victim == fingerprint && (c.victimIndex == i || c.victimIndex == j) {
+			c.victim = 0
+			c.count--
+			return true
+		}
+		return false
+	}
+	// A slot is free now, so the victim finds a place again.
+	if victim := c.victim; victim != 0 {
+		c.victim = 0
+		c.count--
+		c.insert(victim, c.victimIndex)
+	}
+	return true
+}
+
+func (c *Cuckoo) remove(i uint64, fingerprint uint16) bool {
+	bucket := &c.buckets[i]
+	for slot := range bucket {
+		if bucket[slot] == fingerprint {
+			bucket[slot] = 0
+			c.count--
+			return true
+		}
+	}
+	return false
+}
+
+// Merge adds the fingerprints of other to c. Both filters must have the same
+// number of buckets and hasher. If c fills up, the fingerprints merged so far
+// stay and ErrFilterFull is returned.
+func (c *Cuckoo) Merge(other *Cuckoo) error {
+	if len(c.buckets) != len(other.buckets) || c.hasherName != other.hasherName {
+		return fmt.Errorf("sketch: can't merge a cuckoo filter of %d buckets and hasher %s into one of %d buckets and hasher %s",
+			len(other.buckets), other.hasherName, len(c.buckets), c.hasherName)
+	}
+	for i, bucket := range other.buckets {
+		for _, fingerprint := range bucket {
+			if fingerprint == 0 {
+				continue
+			}
+			if err := c.insert(fingerprint, uint64(i)); err != nil {
+				return err
+			}
+		}
+	}
+	if other.victim != 0 {
+		return c.insert(other.victim, other.victimIndex)
+	}
+	return nil
+}
+
+func (c *Cuckoo) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, kindCuckoo, c.hasherName)
+	data = binary.LittleEndian.AppendUint64(data, uint64(len(c.buckets)))
+	data = binary.LittleEndian.AppendUint16(data, c.victim)
+	data = binary.LittleEndian.AppendUint64(data, c.victimIndex)
+	for _, bucket := range c.buckets {
+		for _, fingerprint := range bucket {
+			data = binary.LittleEndian.AppendUint16(data, fingerprint)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary replaces c with the encoded filter.
+func (c *Cuckoo) UnmarshalBinary(data []byte) error {
+	d := &decoder{data: data}
+	name, _ := d.header(kindCuckoo)
+	buckets, victim, victimIndex := d.uint64(), d.uint16(), d.uint64()
+	if d.err != nil {
+		return d.err
+	}
+	if buckets == 0 || buckets&(buckets-1) != 0 || buckets > uint64(len(d.data)/(2*cuckooBucketSize)) || victimIndex >= buckets {
+		return fmt.Errorf("sketch: invalid cuckoo filter of %d buckets", buckets)
+	}
+	decoded, err := newCuckoo(buckets, name)
+	if err != nil {
+		return err
+	}
+	for i := range decoded.buckets {
+		for slot := range decoded.buckets[i] {
+			if decoded.buckets[i][slot] = d.uint16(); decoded.buckets[i][slot] != 0 {
+				decoded.count++
+			}
+		}
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	decoded.victim, decoded.victimIndex = victim, victimIndex
+	if victim != 0 {
+		decoded.count++
+	}
+	*c = *decoded
+	return nil
+}
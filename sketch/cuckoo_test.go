@@ -0,0 +1,146 @@
+package sketch
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCuckooAddContainsDelete(t *testing.T) {
+	const keys = 20000
+	cuckoo, err := NewCuckoo(keys, "")
+	if err != nil {
+		t.Fatalf("NewCuckoo(): %v", err)
+	}
+	for i := 0; i < keys; i++ {
+		if err := cuckoo.Add(fmt.Sprintf("key-%d", i)); err != nil {
+			t.Fatalf("Add(key-%d): %v at %d of %d slots", i, err, cuckoo.Len(), cuckoo.Capacity())
+		}
+	}
+	for i := 0; i < keys; i++ {
+		if !cuckoo.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Contains(key-%d) = false for an added key", i)
+		}
+	}
+
+	// Two buckets of four 16 bit fingerprints give about 8 / 2^16.
+	falsePositives := 0
+	const lookups = 200000
+	for i := 0; i < lookups; i++ {
+		if cuckoo.Contains(fmt.Sprintf("missing-%d", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / lookups; rate > 0.0005 {
+		t.Errorf("false positive rate %.5f, expected at most 0.0005", rate)
+	}
+
+	for i := 0; i < keys; i += 2 {
+		if !cuckoo.Delete(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Delete(key-%d) = false for an added key", i)
+		}
+	}
+	if cuckoo.Len() != keys/2 {
+		t.Errorf("Len() = %d, expected: %d", cuckoo.Len(), keys/2)
+	}
+	for i := 1; i < keys; i += 2 {
+		if !cuckoo.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Contains(key-%d) = false after deleting other keys", i)
+		}
+	}
+	stillFound := 0
+	for i := 0; i < keys; i += 2 {
+		if cuckoo.Contains(fmt.Sprintf("key-%d", i)) {
+			stillFound++
+		}
+	}
+	if stillFound > 10 {
+		t.Errorf("%d deleted keys are still found, expected only fingerprint collisions", stillFound)
+	}
+}
+
+func TestCuckooFull(t *testing.T) {
+	cuckoo, _ := NewCuckoo(100, "")
+	var err error
+	added := 0
+	for ; added < 10*cuckoo.Capacity(); added++ {
+		if err = cuckoo.Add(fmt.Sprintf("key-%d", added)); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrFilterFull) {
+		t.Fatalf("Add() = %v after %d keys, expected ErrFilterFull", err, added)
+	}
+	// The key whose insert failed is still found through the victim.
+	for i := 0; i <= added; i++ {
+		if !cuckoo.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Contains(key-%d) = false in a full filter", i)
+		}
+	}
+	if err := cuckoo.Add("one-more"); !errors.Is(err, ErrFilterFull) {
+		t.Errorf("Add() = %v on a full filter, expected ErrFilterFull", err)
+	}
+
+	// Deleting frees a slot for the victim and the filter accepts keys again.
+	cuckoo.Delete("key-0")
+	if cuckoo.victim != 0 {
+		t.Fatalf("the victim found no slot after a delete")
+	}
+	for i := 1; i <= added; i++ {
+		if !cuckoo.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("Contains(key-%d) = false after the victim moved", i)
+		}
+	}
+}
+
+func TestCuckooMerge(t *testing.T) {
+	a, _ := NewCuckoo(1000, "")
+	b, _ := NewCuckoo(1000, "")
+	for i := 0; i < 400; i++ {
+		a.Add(fmt.Sprintf("a-%d", i))
+		b.Add(fmt.Sprintf("b-%d", i))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+	for i := 0; i < 400; i++ {
+		if !a.Contains(fmt.Sprintf("a-%d", i)) || !a.Contains(fmt.Sprintf("b-%d", i)) {
+			t.Fatalf("merged filter lost key %d", i)
+		}
+	}
+	// Merged fingerprints can be deleted by key like added ones.
+	if !a.Delete("b-7") || a.Len() != 799 {
+		t.Errorf("Delete(b-7) after Merge, Len() = %d, expected: 799", a.Len())
+	}
+
+	small, _ := NewCuckoo(10, "")
+	if err := a.Merge(small); err == nil {
+		t.Errorf("Merge of a differently sized filter succeeded, expected an error")
+	}
+}
+
+func TestCuckooBinaryRoundTrip(t *testing.T) {
+	cuckoo, _ := NewCuckoo(1000, "")
+	for i := 0; i < 900; i++ {
+		cuckoo.Add(fmt.Sprintf("key-%d", i))
+	}
+	data, err := cuckoo.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+	var decoded Cuckoo
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if decoded.Len() != cuckoo.Len() || decoded.Capacity() != cuckoo.Capacity() {
+		t.Errorf("decoded Len() = %d, Capacity() = %d, expected: %d, %d", decoded.Len(), decoded.Capacity(), cuckoo.Len(), cuckoo.Capacity())
+	}
+	for i := 0; i < 900; i++ {
+		if !decoded.Contains(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("decoded filter lost key-%d", i)
+		}
+	}
+	if err := decoded.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Errorf("UnmarshalBinary with trailing data succeeded, expected an error")
+	}
+}
@@ -0,0 +1,96 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Every encoded sketch starts with a header: a kind byte, a format version
+// byte and the length-prefixed name of its hasher. The fields of the sketch
+// follow as little endian integers.
+const (
+	kindBloom       byte = 'B'
+	kindCuckoo      byte = 'C'
+	kindHyperLogLog byte = 'H'
+
+	encodingVersion byte = 1
+)
+
+var errTruncated = errors.New("sketch: truncated data")
+
+func appendHeader(b []byte, kind byte, hasher string) []byte {
+	b = append(b, kind, encodingVersion, byte(len(hasher)))
+	return append(b, hasher...)
+}
+
+// decoder reads the fields of an encoded sketch and remembers the first
+// error, so callers check it once at the end.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = errTruncated
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint8() uint8 {
+	if b := d.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint16() uint16 {
+	if b := d.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if b := d.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// header checks the kind and version and returns the hasher name and hasher.
+func (d *decoder) header(kind byte) (string, Hasher) {
+	gotKind, version := d.uint8(), d.uint8()
+	name := string(d.next(int(d.uint8())))
+	if d.err != nil {
+		return "", nil
+	}
+	if gotKind != kind {
+		d.err = fmt.Errorf("sketch: data holds a %q sketch, expected %q", gotKind, kind)
+		return "", nil
+	}
+	if version != encodingVersion {
+		d.err = fmt.Errorf("sketch: unsupported encoding version %d", version)
+		return "", nil
+	}
+	hasher, err := LookupHasher(name)
+	if err != nil {
+		d.err = err
+	}
+	return name, hasher
+}
+
+// finish returns the first error, or an error if data is left over.
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("sketch: %d trailing bytes", len(d.data))
+	}
+	return d.err
+}
@@ -0,0 +1,102 @@
+// Package sketch provides probabilistic structures that answer membership
+// and cardinality questions in a fixed amount of memory: a Bloom filter, a
+// cuckoo filter that supports deletes and a HyperLogLog estimator. They hash
+// keys with the same FNV-1a function as the hash table, or with any other
+// hasher registered by name.
+package sketch
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sync"
+)
+
+const (
+	fnvOffset uint64 = 14695981039346656037
+	fnvPrime  uint64 = 1099511628211
+)
+
+// Hasher maps a key to a 64 bit hash.
+type Hasher func(key string) uint64
+
+// DefaultHasher is the name of the hasher used when none is given.
+const DefaultHasher = "fnv1a"
+
+var (
+	hashersMu sync.RWMutex
+	hashers   = map[string]Hasher{
+		"fnv1a":     FNV1a,
+		"fnv1a-lib": fnv1aLib,
+	}
+)
+
+// FNV1a is the FNV-1a hash of key, the hash function of the hash table.
+func FNV1a(key string) uint64 {
+	hash := fnvOffset
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= fnvPrime
+	}
+	return hash
+}
+
+// fnv1aLib computes FNV-1a with the standard library, for comparison.
+func fnv1aLib(key string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(key))
+	return hash.Sum64()
+}
+
+// RegisterHasher makes a hasher available by name to the constructors and to
+// decoding. Names can't be registered twice.
+func RegisterHasher(name string, hasher Hasher) error {
+	if name == "" || len(name) > 255 {
+		return fmt.Errorf("invalid hasher name %q", name)
+	}
+	hashersMu.Lock()
+	defer hashersMu.Unlock()
+	if _, ok := hashers[name]; ok {
+		return fmt.Errorf("hasher %q is already registered", name)
+	}
+	hashers[name] = hasher
+	return nil
+}
+
+// LookupHasher returns the hasher registered as name. An empty name selects
+// DefaultHasher.
+func LookupHasher(name string) (Hasher, error) {
+	if name == "" {
+		name = DefaultHasher
+	}
+	hashersMu.RLock()
+	defer hashersMu.RUnlock()
+	hasher, ok := hashers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hasher %q", name)
+	}
+	return hasher, nil
+}
+
+// Hashers returns the names of the registered hashers in sorted order.
+func Hashers() []string {
+	hashersMu.RLock()
+	defer hashersMu.RUnlock()
+	names := make([]string, 0, len(hashers))
+	for name := range hashers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// mix64 is the finalizer of MurmurHash3. It spreads every input bit over the
+// whole output, which HyperLogLog needs from the top and bottom bits.
+func mix64(hash uint64) uint64 {
+	hash ^= hash >> 33
+	hash *= 0xff51afd7ed558ccd
+	hash ^= hash >> 33
+	hash *= 0xc4ceb9fe1a85ec53
+	hash ^= hash >> 33
+	return hash
+}
@@ -0,0 +1,38 @@
+package sketch
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFNV1aMatchesLibrary(t *testing.T) {
+	for _, key := range []string{"", "a", "key-1", "a somewhat longer key of 36 bytes.."} {
+		if got, expected := FNV1a(key), fnv1aLib(key); got != expected {
+			t.Errorf("FNV1a(%q) = %016x, expected: %016x", key, got, expected)
+		}
+	}
+}
+
+func TestRegisterHasher(t *testing.T) {
+	constant := func(string) uint64 { return 42 }
+	if err := RegisterHasher("test-constant", constant); err != nil {
+		t.Fatalf("RegisterHasher(test-constant): %v", err)
+	}
+	if err := RegisterHasher("test-constant", constant); err == nil {
+		t.Errorf("RegisterHasher(test-constant) twice succeeded, expected an error")
+	}
+	if err := RegisterHasher("", constant); err == nil {
+		t.Errorf("RegisterHasher with an empty name succeeded, expected an error")
+	}
+
+	hasher, err := LookupHasher("test-constant")
+	if err != nil || hasher("anything") != 42 {
+		t.Errorf("LookupHasher(test-constant) = %v, expected the registered hasher", err)
+	}
+	if _, err := LookupHasher("missing"); err == nil {
+		t.Errorf("LookupHasher(missing) succeeded, expected an error")
+	}
+	if !slices.Contains(Hashers(), "test-constant") || !slices.Contains(Hashers(), DefaultHasher) {
+		t.Errorf("Hashers() = %v, expected the default and the registered hasher", Hashers())
+	}
+}
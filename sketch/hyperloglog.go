@@ -0,0 +1,133 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// HyperLogLog estimates the number of distinct keys added to it. With
+// precision p it keeps 2^p one byte registers and has a standard error of
+// about 1.04 / sqrt(2^p), 0.8% for p = 14.
+type HyperLogLog struct {
+	registers  []uint8
+	precision  uint8
+	hasherName string
+	hasher     Hasher
+}
+
+func NewHyperLogLog(precision int, hasher string) (*HyperLogLog, error) {
+	if precision < MinPrecision || precision > MaxPrecision {
+		return nil, fmt.Errorf("sketch: precision %d is not between %d and %d", precision, MinPrecision, MaxPrecision)
+	}
+	if hasher == "" {
+		hasher = DefaultHasher
+	}
+	h, err := LookupHasher(hasher)
+	if err != nil {
+		return nil, err
+	}
+	return &HyperLogLog{
+		registers:  make([]uint8, 1<<precision),
+		precision:  uint8(precision),
+		hasherName: hasher,
+		hasher:     h,
+	}, nil
+}
+
+// Add records key. The hash is mixed first, since the register index comes
+// from its top bits and FNV-1a leaves them poorly distributed for short keys.
+func (h *HyperLogLog) Add(key string) {
+	hash := mix64(h.hasher(key))
+	index := hash >> (64 - h.precision)
+	// The rank is the position of the first set bit in the remaining bits.
+	rest := hash<<h.precision | 1<<(h.precision-1)
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Count returns the estimated number of distinct keys.
+func (h *HyperLogLog) Count() uint64 {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, register := range h.registers {
+		sum += math.Ldexp(1, -int(register))
+		if register == 0 {
+			zeros++
+		}
+	}
+	estimate := h.alpha() * m * m / sum
+	// Small cardinalities are counted more precisely by linear counting of
+	// the empty registers. With 64 bit hashes no large range correction is
+	// needed.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
+
+func (h *HyperLogLog) alpha() float64 {
+	switch len(h.registers) {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	}
+	return 0.7213 / (1 + 1.079/float64(len(h.registers)))
+}
+
+// Merge adds the keys counted by other to h, so h estimates the cardinality
+// of the union. Both must have the same precision and hasher.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.precision != other.precision || h.hasherName != other.hasherName {
+		return fmt.Errorf("sketch: can't merge a HyperLogLog of precision %d and hasher %s into one of precision %d and hasher %s",
+			other.precision, other.hasherName, h.precision, h.hasherName)
+	}
+	for i, register := range other.registers {
+		h.registers[i] = max(h.registers[i], register)
+	}
+	return nil
+}
+
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	data := appendHeader(nil, kindHyperLogLog, h.hasherName)
+	data = append(data, h.precision)
+	return append(data, h.registers...), nil
+}
+
+// UnmarshalBinary replaces h with the encoded estimator.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	d := &decoder{data: data}
+	name, _ := d.header(kindHyperLogLog)
+	precision := d.uint8()
+	if d.err != nil {
+		return d.err
+	}
+	decoded, err := NewHyperLogLog(int(precision), name)
+	if err != nil {
+		return err
+	}
+	registers := d.next(len(decoded.registers))
+	if err := d.finish(); err != nil {
+		return err
+	}
+	maxRank := 64 - precision + 1
+	for _, register := range registers {
+		if register > maxRank {
+			return fmt.Errorf("sketch: register value %d exceeds %d", register, maxRank)
+		}
+	}
+	copy(decoded.registers, registers)
+	*h = *decoded
+	return nil
+}
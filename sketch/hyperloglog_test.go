@@ -0,0 +1,88 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHyperLogLogAccuracy(t *testing.T) {
+	const precision = 14
+	// Four standard errors; the estimates are deterministic, so this only
+	// guards against a broken estimator.
+	tolerance := 4 * 1.04 / math.Sqrt(1<<precision)
+	for _, hasher := range []string{"fnv1a", "fnv1a-lib"} {
+		for _, cardinality := range []int{10, 100, 1000, 10000, 100000, 1000000} {
+			hll, err := NewHyperLogLog(precision, hasher)
+			if err != nil {
+				t.Fatalf("NewHyperLogLog(): %v", err)
+			}
+			for i := 0; i < cardinality; i++ {
+				key := fmt.Sprintf("key-%d", i)
+				hll.Add(key)
+				// Repeats must not change the estimate.
+				if i%3 == 0 {
+					hll.Add(key)
+				}
+			}
+			estimate := hll.Count()
+			relative := math.Abs(float64(estimate)-float64(cardinality)) / float64(cardinality)
+			if relative > tolerance && math.Abs(float64(estimate)-float64(cardinality)) > 1 {
+				t.Errorf("%s: Count() = %d for %d keys, relative error %.4f exceeds %.4f", hasher, estimate, cardinality, relative, tolerance)
+			}
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, _ := NewHyperLogLog(12, "")
+	b, _ := NewHyperLogLog(12, "")
+	for i := 0; i < 60000; i++ {
+		a.Add(fmt.Sprintf("key-%d", i))
+		b.Add(fmt.Sprintf("key-%d", i+40000))
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("Merge(): %v", err)
+	}
+	// The union holds 100000 distinct keys.
+	if estimate := a.Count(); estimate < 95000 || estimate > 105000 {
+		t.Errorf("Count() of the union = %d, expected about 100000", estimate)
+	}
+
+	other, _ := NewHyperLogLog(10, "")
+	if err := a.Merge(other); err == nil {
+		t.Errorf("Merge of a different precision succeeded, expected an error")
+	}
+}
+
+func TestHyperLogLogBinaryRoundTrip(t *testing.T) {
+	hll, _ := NewHyperLogLog(10, "fnv1a-lib")
+	for i := 0; i < 5000; i++ {
+		hll.Add(fmt.Sprintf("key-%d", i))
+	}
+	data, err := hll.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+	var decoded HyperLogLog
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if decoded.Count() != hll.Count() || decoded.hasherName != "fnv1a-lib" {
+		t.Errorf("decoded Count() = %d with hasher %s, expected: %d with fnv1a-lib", decoded.Count(), decoded.hasherName, hll.Count())
+	}
+
+	data[len(data)-1] = 64
+	if err := decoded.UnmarshalBinary(data); err == nil {
+		t.Errorf("UnmarshalBinary with an impossible register succeeded, expected an error")
+	}
+}
+
+func TestNewHyperLogLogInvalid(t *testing.T) {
+	if _, err := NewHyperLogLog(MinPrecision-1, ""); err == nil {
+		t.Errorf("NewHyperLogLog(%d) succeeded, expected an error", MinPrecision-1)
+	}
+	if _, err := NewHyperLogLog(MaxPrecision+1, ""); err == nil {
+		t.Errorf("NewHyperLogLog(%d) succeeded, expected an error", MaxPrecision+1)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import "time"
+
+// Number of buckets of a probe length histogram. Bucket i counts the
+// operations that probed i slots past their home slot, the last bucket also
+// counts every longer probe sequence.
+const probeHistogramBuckets int = 16
+
+type probeStats struct {
+	operations  uint64
+	totalProbes uint64
+	maxProbes   uint64
+	histogram   [probeHistogramBuckets]uint64
+}
+
+func (p *probeStats) record(probes uint64) {
+	p.operations++
+	p.totalProbes += probes
+	if probes > p.maxProbes {
+		p.maxProbes = probes
+	}
+	bucket := probes
+	if bucket >= uint64(probeHistogramBuckets) {
+		bucket = uint64(probeHistogramBuckets) - 1
+	}
+	p.histogram[bucket]++
+}
+
+func (p *probeStats) snapshot() ProbeStats {
+	average := 0.0
+	if p.operations > 0 {
+		average = float64(p.totalProbes) / float64(p.operations)
+	}
+	histogram := make([]uint64, probeHistogramBuckets)
+	copy(histogram, p.histogram[:])
+	return ProbeStats{
+		Operations:   p.operations,
+		TotalProbes:  p.totalProbes,
+		AverageProbe: average,
+		MaxProbe:     p.maxProbes,
+		Histogram:    histogram,
+	}
+}
+
+// tableStats holds the instrumentation counters of a HashTable. Re-inserts
+// done by resize are not counted as inserts.
+type tableStats struct {
+	inserts    probeStats
+	lookups    probeStats
+	deletes    uint64
+	grows      uint64
+	shrinks    uint64
+	resizeTime time.Duration
+	// Outcomes of the Bloom filter checks.
+	filterRejects        uint64
+	filterPasses         uint64
+	filterFalsePositives uint64
+}
+
+// ProbeStats describes the probe sequence lengths of one kind of operation.
+// A probe length is the number of slots visited after the home slot.
+type ProbeStats struct {
+	Operations   uint64
+	TotalProbes  uint64
+	AverageProbe float64
+	MaxProbe     uint64
+	// Histogram[i] is the number of operations with a probe length of i. The
+	// last bucket also holds every longer probe length.
+	Histogram []uint64
+}
+
+type Stats struct {
+	Live       uint64
+	Tombstones uint64
+	Capacity   uint64
+	LoadFactor float32
+	Inserts    ProbeStats
+	// Lookups covers Search and the search part of Delete, except for the
+	// lookups the Bloom filter answered without probing. It is only recorded
+	// after EnableLookupStats.
+	Lookups ProbeStats
+	// Deletes is the number of keys removed by Delete.
+	Deletes    uint64
+	Grows      uint64
+	Shrinks    uint64
+	ResizeTime time.Duration
+	// Filter is nil unless EnableFilter was called.
+	Filter *FilterStats
+}
+
+// FilterStats describes the Bloom filter of a table.
+type FilterStats struct {
+	Bits   uint64
+	Hashes uint64
+	// FillRatio is the fraction of bits set.
+	FillRatio float64
+	// Rejects is the number of lookups answered by the filter alone.
+	Rejects uint64
+	// Passes is the number of lookups the filter let through to the slots,
+	// FalsePositives the number of those that did not find their key.
+	Passes         uint64
+	FalsePositives uint64
+	// FalsePositiveRate is FalsePositives over all lookups of missing keys.
+	FalsePositiveRate float64
+}
+
+// EnableLookupStats makes Search, and the search part of Delete, record their
+// probe lengths and filter outcomes in Stats. Recording writes to the table
+// on every lookup, so the table must then not be read concurrently either.
+// Inserts, deletes and resizes are always counted. The setting is kept when
+// the table is decoded.
+func (h *HashTable[V]) EnableLookupStats() {
+	h.lookupStats = true
+}
+
+func (h *HashTable[V]) recordLookup(probes uint64) {
+	if h.lookupStats {
+		h.stats.lookups.record(probes)
+	}
+}
+
+// Stats returns a snapshot of the table instrumentation. Lookups and the
+// filter counters stay zero unless EnableLookupStats was called.
+func (h *HashTable[V]) Stats() Stats {
+	return Stats{
+		Live:       h.activeSlotCounter,
+		Tombstones: h.occupiedSlotCounter - h.activeSlotCounter,
+		Capacity:   h.length,
+		LoadFactor: h.computeLoadFactor(),
+		Inserts:    h.stats.inserts.snapshot(),
+		Lookups:    h.stats.lookups.snapshot(),
+		Deletes:    h.stats.deletes,
+		Grows:      h.stats.grows,
+		Shrinks:    h.stats.shrinks,
+		ResizeTime: h.stats.resizeTime,
+		Filter:     h.filterStats(),
+	}
+}
+
+func (h *HashTable[V]) filterStats() *FilterStats {
+	if h.filter == nil {
+		return nil
+	}
+	stats := &FilterStats{
+		Bits:           h.filter.bloom.Bits(),
+		Hashes:         h.filter.bloom.Hashes(),
+		FillRatio:      h.filter.bloom.FillRatio(),
+		Rejects:        h.stats.filterRejects,
+		Passes:         h.stats.filterPasses,
+		FalsePositives: h.stats.filterFalsePositives,
+	}
+	if misses := stats.Rejects + stats.FalsePositives; misses > 0 {
+		stats.FalsePositiveRate = float64(stats.FalsePositives) / float64(misses)
+	}
+	return stats
+}
+
+// ResetStats zeroes the operation counters. Live, Tombstones, Capacity and
+// LoadFactor describe the current contents and are not affected.
+func (h *HashTable[V]) ResetStats() {
+	h.stats = tableStats{}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestStatsCounters(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.EnableLookupStats()
+	hashTable.Insert("foo-1", 1)
+	hashTable.Insert("foo-2", 2)
+	hashTable.Insert("foo-3", 3)
+	hashTable.Delete("foo-2")
+	hashTable.Search("foo-1")
+	hashTable.Search("foo-missing")
+
+	stats := hashTable.Stats()
+	if stats.Live != 2 {
+		t.Errorf("Stats().Live = %d, expected: 2", stats.Live)
+	}
+	if stats.Tombstones != 1 {
+		t.Errorf("Stats().Tombstones = %d, expected: 1", stats.Tombstones)
+	}
+	if stats.Capacity != 17 {
+		t.Errorf("Stats().Capacity = %d, expected: 17", stats.Capacity)
+	}
+	if stats.Inserts.Operations != 3 {
+		t.Errorf("Stats().Inserts.Operations = %d, expected: 3", stats.Inserts.Operations)
+	}
+	if stats.Lookups.Operations != 3 {
+		t.Errorf("Stats().Lookups.Operations = %d, expected: 3", stats.Lookups.Operations)
+	}
+	if stats.Deletes != 1 {
+		t.Errorf("Stats().Deletes = %d, expected: 1", stats.Deletes)
+	}
+}
+
+func TestStatsProbeLengths(t *testing.T) {
+	var tableLength uint64 = 389
+	keys := findCollidingKeys(10, tableLength)
+	hashTable := buildHashTable(keys, tableLength)
+
+	stats := hashTable.Stats()
+	if stats.Inserts.MaxProbe == 0 {
+		t.Fatalf("Stats().Inserts.MaxProbe = 0, expected colliding keys to probe")
+	}
+	var histogramTotal uint64
+	for _, count := range stats.Inserts.Histogram {
+		histogramTotal += count
+	}
+	if histogramTotal != stats.Inserts.Operations {
+		t.Errorf("histogram holds %d operations, expected: %d", histogramTotal, stats.Inserts.Operations)
+	}
+	if stats.Inserts.Histogram[0] != 1 {
+		t.Errorf("Histogram[0] = %d, expected only the first key to land on its home slot", stats.Inserts.Histogram[0])
+	}
+	expectedAverage := float64(stats.Inserts.TotalProbes) / float64(stats.Inserts.Operations)
+	if stats.Inserts.AverageProbe != expectedAverage {
+		t.Errorf("Stats().Inserts.AverageProbe = %f, expected: %f", stats.Inserts.AverageProbe, expectedAverage)
+	}
+}
+
+func TestStatsResizeHistory(t *testing.T) {
+	hashTable := New[int](10)
+	for i := 0; i < 100; i++ {
+		hashTable.Insert(fmt.Sprintf("foo-%d", i), i)
+	}
+	// Tombstones count towards the load factor, so shrinking needs a sparse table.
+	sparseTable := New[int](40)
+	sparseTable.Insert("foo-1", 1)
+	sparseTable.Delete("foo-1")
+	if sparseTable.Stats().Shrinks != 1 {
+		t.Errorf("Stats().Shrinks = %d, expected: 1", sparseTable.Stats().Shrinks)
+	}
+
+	stats := hashTable.Stats()
+	if stats.Grows != 3 {
+		t.Errorf("Stats().Grows = %d, expected: 3", stats.Grows)
+	}
+	if stats.Inserts.Operations != 100 {
+		t.Errorf("Stats().Inserts.Operations = %d, expected resizes not to count as inserts", stats.Inserts.Operations)
+	}
+
+	hashTable.ResetStats()
+	stats = hashTable.Stats()
+	if stats.Grows != 0 || stats.Inserts.Operations != 0 || stats.ResizeTime != 0 {
+		t.Errorf("Stats() after ResetStats = %+v, expected zeroed counters", stats)
+	}
+}
+
+func TestSearchDoesNotRecordByDefault(t *testing.T) {
+	table := buildHashTable(makeSequentialKeys(1000), 10)
+	table.EnableFilter(0)
+	before := table.stats
+
+	// Concurrent readers rely on Search not writing; go test -race checks it.
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 2000; i++ {
+				table.Search(fmt.Sprintf("key-%d", i))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if table.stats != before {
+		t.Errorf("Stats() = %+v, expected Search not to record without EnableLookupStats", table.Stats())
+	}
+}
+
+func TestDecodingKeepsLookupStats(t *testing.T) {
+	encoded, err := buildHashTable(makeSequentialKeys(10), 10).GobEncode()
+	if err != nil {
+		t.Fatalf("GobEncode(): %v", err)
+	}
+	table := New[int](10)
+	table.EnableLookupStats()
+	if err := table.GobDecode(encoded); err != nil {
+		t.Fatalf("GobDecode(): %v", err)
+	}
+	table.Search("key-1")
+	if lookups := table.Stats().Lookups.Operations; lookups != 1 {
+		t.Errorf("Lookups.Operations = %d after GobDecode, expected: 1", lookups)
+	}
+
+	var zero HashTable[int]
+	zero.EnableLookupStats()
+	if err := zero.ReadJSON(strings.NewReader(`{"key-1": 1}`)); err != nil {
+		t.Fatalf("ReadJSON(): %v", err)
+	}
+	zero.Search("key-1")
+	if lookups := zero.Stats().Lookups.Operations; lookups != 1 {
+		t.Errorf("Lookups.Operations = %d after ReadJSON, expected: 1", lookups)
+	}
+}
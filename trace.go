@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ReadTrace reads a key trace with one requested key per line. Blank lines
+// are skipped.
+func ReadTrace(r io.Reader) ([]string, error) {
+	var keys []string
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		key := strings.TrimSpace(scanner.Text())
+		if key == "" {
+			continue
+		}
+		if err := validateKey(key); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+type TraceResult struct {
+	Policy   string  `json:"policy"`
+	Size     int     `json:"size"`
+	Requests int     `json:"requests"`
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// ReplayTrace requests every key of trace from a cache of the given policy
+// and size, adding the key on a miss, and reports the hit ratio.
+func ReplayTrace(trace []string, policy CachePolicy, size int) (TraceResult, error) {
+	cache, err := NewCache(CacheOptions[struct{}]{Policy: policy, MaxEntries: size})
+	if err != nil {
+		return TraceResult{}, err
+	}
+	for _, key := range trace {
+		if _, ok := cache.Get(key); !ok {
+			cache.Put(key, struct{}{})
+		}
+	}
+
+	stats := cache.Stats()
+	result := TraceResult{
+		Policy:   policy.String(),
+		Size:     size,
+		Requests: len(trace),
+		Hits:     stats.Hits,
+		Misses:   stats.Misses,
+	}
+	if len(trace) > 0 {
+		result.HitRatio = float64(stats.Hits) / float64(len(trace))
+	}
+	return result, nil
+}
+
+func writeTraceCSV(w io.Writer, results []TraceResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"policy", "size", "requests", "hits", "misses", "hit_ratio"})
+	for _, result := range results {
+		cw.Write([]string{
+			result.Policy,
+			strconv.Itoa(result.Size),
+			strconv.Itoa(result.Requests),
+			strconv.FormatUint(result.Hits, 10),
+			strconv.FormatUint(result.Misses, 10),
+			strconv.FormatFloat(result.HitRatio, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTraceJSON(w io.Writer, results []TraceResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// runReplay implements the replay command: it replays a recorded key trace
+// against every cache policy for every cache size and reports the hit ratios.
+func runReplay(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	tracePath := flags.String("trace", "", "file with one requested key per line, - for stdin")
+	policies := flags.String("policies", "lru,clock,2q,tinylfu", "comma separated cache policies: lru, clock, 2q, tinylfu")
+	sizes := flags.String("sizes", "1000", "comma separated cache sizes in entries")
+	format := flags.String("format", "csv", "output format: csv or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *tracePath == "" {
+		return errors.New("replay: -trace is required")
+	}
+	var write func(io.Writer, []TraceResult) error
+	switch *format {
+	case "csv":
+		write = writeTraceCSV
+	case "json":
+		write = writeTraceJSON
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+
+	in := os.Stdin
+	if *tracePath != "-" {
+		file, err := os.Open(*tracePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	trace, err := ReadTrace(in)
+	if err != nil {
+		return err
+	}
+
+	var results []TraceResult
+	for _, sizeSpec := range strings.Split(*sizes, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(sizeSpec))
+		if err != nil || size < 1 {
+			return fmt.Errorf("invalid cache size %q", sizeSpec)
+		}
+		for _, name := range strings.Split(*policies, ",") {
+			policy, err := ParseCachePolicy(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			result, err := ReplayTrace(trace, policy, size)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+	}
+	return write(os.Stdout, results)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// scanTrace alternates between requests for a zipfian distributed working set
+// and scans over keys that are requested only once.
+func scanTrace() []string {
+	r := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(r, 1.1, 1, 999)
+	var trace []string
+	scanned := 0
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 5000; i++ {
+			trace = append(trace, fmt.Sprintf("hot-%d", zipf.Uint64()))
+		}
+		for i := 0; i < 2000; i++ {
+			trace = append(trace, fmt.Sprintf("scan-%d", scanned))
+			scanned++
+		}
+	}
+	return trace
+}
+
+func TestReplayTraceScanResistance(t *testing.T) {
+	trace := scanTrace()
+	ratios := make(map[CachePolicy]float64)
+	for _, policy := range allCachePolicies {
+		result, err := ReplayTrace(trace, policy, 200)
+		if err != nil {
+			t.Fatalf("ReplayTrace(%s) returned error: %v", policy, err)
+		}
+		if result.Hits+result.Misses != uint64(len(trace)) {
+			t.Errorf("ReplayTrace(%s) counted %d requests, expected: %d", policy, result.Hits+result.Misses, len(trace))
+		}
+		ratios[policy] = result.HitRatio
+	}
+	t.Logf("hit ratios: %v", ratios)
+
+	for _, policy := range []CachePolicy{Policy2Q, PolicyTinyLFU} {
+		if ratios[policy] <= ratios[PolicyLRU] {
+			t.Errorf("%s hit ratio %.4f, expected it to beat LRU on scans: %.4f", policy, ratios[policy], ratios[PolicyLRU])
+		}
+	}
+}
+
+func TestReadTrace(t *testing.T) {
+	trace, err := ReadTrace(strings.NewReader("foo\n\n  bar \nfoo\n"))
+	if err != nil {
+		t.Fatalf("ReadTrace returned error: %v", err)
+	}
+	if strings.Join(trace, ",") != "foo,bar,foo" {
+		t.Errorf("ReadTrace = %v, expected: [foo bar foo]", trace)
+	}
+
+	tooLong := strings.Repeat("x", maxKeyLength+1)
+	if _, err := ReadTrace(strings.NewReader("foo\n" + tooLong)); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadTrace with a long key = %v, expected an error for line 2", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate checks the internal consistency of the table and returns an error
+// describing every violated invariant, or nil if the table is consistent:
+//   - length matches the length of the slots array
+//   - the slot counters match the states of the slots
+//   - every occupied key is stored with its own hash
+//   - every occupied key is reachable from its home slot by following the
+//     probe sequence without crossing an empty slot
+//   - no key is stored more than once
+//   - the Bloom filter, if enabled, contains every occupied key
+func (h *HashTable[V]) Validate() error {
+	var problems []error
+
+	if h.length != uint64(len(h.slots)) {
+		problems = append(problems, fmt.Errorf("length is %d but there are %d slots", h.length, len(h.slots)))
+		// The probe sequence depends on length, so nothing else can be checked.
+		return errors.Join(problems...)
+	}
+
+	var occupied, tombstones uint64
+	seen := make(map[string]int, h.activeSlotCounter)
+
+	for i := range h.slots {
+		item := &h.slots[i]
+		switch item.state {
+		case slotEmpty:
+			continue
+		case slotTombstone:
+			tombstones++
+			continue
+		case slotOccupied:
+			occupied++
+		default:
+			problems = append(problems, fmt.Errorf("slot %d has unknown state %d", i, item.state))
+			continue
+		}
+
+		key := item.key.value
+		if first, ok := seen[key]; ok {
+			problems = append(problems, fmt.Errorf("key %q is stored in slots %d and %d", key, first, i))
+		} else {
+			seen[key] = i
+		}
+
+		if hash := fnvHash(key); item.key.hash != hash {
+			problems = append(problems, fmt.Errorf("key %q in slot %d has hash %016x, expected: %016x", key, i, item.key.hash, hash))
+			continue
+		}
+
+		if err := h.checkReachable(item.key, uint64(i)); err != nil {
+			problems = append(problems, err)
+		}
+		if h.filter != nil && !h.filter.mayContain(item.key.hash) {
+			problems = append(problems, fmt.Errorf("key %q in slot %d is missing from the filter", key, i))
+		}
+	}
+
+	if h.activeSlotCounter != occupied {
+		problems = append(problems, fmt.Errorf("activeSlotCounter is %d but %d slots are occupied", h.activeSlotCounter, occupied))
+	}
+	if h.occupiedSlotCounter != occupied+tombstones {
+		problems = append(problems, fmt.Errorf("occupiedSlotCounter is %d but %d slots are occupied or tombstones", h.occupiedSlotCounter, occupied+tombstones))
+	}
+
+	return errors.Join(problems...)
+}
+
+// checkReachable follows the probe sequence of key and returns an error if it
+// hits an empty slot, or wraps around, before reaching index.
+func (h *HashTable[V]) checkReachable(key nodeKey, index uint64) error {
+	homeLocation := h.doubleHashing(key, 0)
+	for collisionCount := uint64(0); ; collisionCount++ {
+		location := h.doubleHashing(key, collisionCount)
+		if location == index {
+			return nil
+		}
+		if collisionCount > 0 && location == homeLocation {
+			return fmt.Errorf("key %q in slot %d is not on its probe sequence from home slot %d", key.value, index, homeLocation)
+		}
+		if h.slots[location].state == slotEmpty {
+			return fmt.Errorf("key %q in slot %d is unreachable: empty slot %d after %d probes from home slot %d", key.value, index, location, collisionCount, homeLocation)
+		}
+	}
+}
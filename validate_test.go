@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func assertValidationProblem(t *testing.T, err error, expected string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Validate = nil, expected an error containing %q", expected)
+	}
+	if !strings.Contains(err.Error(), expected) {
+		t.Errorf("Validate = %v, expected an error containing %q", err, expected)
+	}
+}
+
+func TestValidateAfterOperations(t *testing.T) {
+	hashTable := New[int](10)
+	if err := hashTable.Validate(); err != nil {
+		t.Fatalf("Validate on empty table returned error: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		hashTable.Insert(fmt.Sprintf("foo-%d", i), i)
+	}
+	for i := 0; i < 1000; i += 2 {
+		hashTable.Delete(fmt.Sprintf("foo-%d", i))
+	}
+	for i := 0; i < 1000; i += 4 {
+		hashTable.Insert(fmt.Sprintf("foo-%d", i), i)
+	}
+	if err := hashTable.Validate(); err != nil {
+		t.Errorf("Validate returned error: %v", err)
+	}
+}
+
+func TestValidateDetectsCounterMismatch(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	hashTable.activeSlotCounter++
+	hashTable.occupiedSlotCounter = 0
+
+	err := hashTable.Validate()
+	assertValidationProblem(t, err, "activeSlotCounter is 2 but 1 slots are occupied")
+	assertValidationProblem(t, err, "occupiedSlotCounter is 0")
+}
+
+func TestValidateDetectsLengthMismatch(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.length = 23
+	assertValidationProblem(t, hashTable.Validate(), "length is 23 but there are 17 slots")
+}
+
+func TestValidateDetectsUnreachableKey(t *testing.T) {
+	var length uint64 = 389
+	keys := findCollidingKeys(3, length)
+	hashTable := buildHashTable(keys, length)
+
+	// Emptying the home slot without leaving a tombstone cuts the chain.
+	home := hashTable.doubleHashing(NewKey(keys[0]), 0)
+	hashTable.slots[home] = data[int]{}
+	hashTable.activeSlotCounter--
+	hashTable.occupiedSlotCounter--
+
+	assertValidationProblem(t, hashTable.Validate(), "is unreachable")
+}
+
+func TestValidateDetectsDuplicateKeys(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	home := hashTable.doubleHashing(NewKey("foo-1"), 0)
+	next := hashTable.doubleHashing(NewKey("foo-1"), 1)
+	hashTable.slots[next] = hashTable.slots[home]
+	hashTable.activeSlotCounter++
+	hashTable.occupiedSlotCounter++
+
+	assertValidationProblem(t, hashTable.Validate(), `key "foo-1" is stored in slots`)
+}
+
+func TestValidateDetectsWrongHash(t *testing.T) {
+	hashTable := New[int](10)
+	hashTable.Insert("foo-1", 1)
+	home := hashTable.doubleHashing(NewKey("foo-1"), 0)
+	hashTable.slots[home].key.value = "foo-2"
+
+	assertValidationProblem(t, hashTable.Validate(), `key "foo-2" in slot`)
+}